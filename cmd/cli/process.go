@@ -1,12 +1,18 @@
 package main
 
 /*
-process a scorecard document
+process a scorecard document, or validate it with the validate command
 */
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/manager"
@@ -14,18 +20,15 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validate(os.Args[2:]))
+	}
 	os.Exit(process())
 }
 
-func process() int {
-	defer fmt.Println("Finished")
-	if len(os.Args) != 2 {
-		fmt.Println("Usage:", os.Args[0], "document_id")
-		return 1
-	}
-
-	documentID := os.Args[1]
-	start := time.Now()
+// loadEnvironmentFile loads the environment from PROC_ENV_PATH, or .env if it isn't set.
+// It returns false if PROC_ENV_PATH is set and can't be loaded.
+func loadEnvironmentFile() bool {
 	environmentFile, set := os.LookupEnv("PROC_ENV_PATH")
 	if !set {
 		err := godotenv.Load() // Loads from "$(pwd)/.env"
@@ -36,17 +39,91 @@ func process() int {
 		err := godotenv.Load(environmentFile) // Loads from whatever PROC_ENV_PATH has been set to
 		if err != nil {
 			log.Printf("Couldn't load environment file: %q", environmentFile)
-			return 7
+			return false
+		}
+	}
+	return true
+}
+
+func process() int {
+	defer fmt.Println("Finished")
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "render and check every query without running it, nothing is written to the document")
+	jsonReport := flags.Bool("json", false, "print the dry run report as JSON instead of text")
+	input := flags.String("input", "", "process the scorecard document in this file instead of a document in couchbase")
+	output := flags.String("output", "", "write the processed document from -input to this file")
+	resume := flags.String("resume", "off", "off, unfilled or errors - only process the cells that are unfilled or that failed in an earlier run")
+	block := flags.String("block", "", "only process this results block")
+	region := flags.String("region", "", "only process this region of the blocks")
+	cell := flags.String("cell", "", `only process the cells below this keychain e.g. "All HRRR domain -> RMSE -> 2m temperature"`)
+	err := flags.Parse(os.Args[1:])
+	var resumeMode manager.ResumeMode
+	var scope manager.Scope
+	if err == nil {
+		// the flag set prints its own errors
+		resumeMode, err = manager.ParseResumeMode(*resume)
+		if err == nil {
+			scope, err = manager.ParseScope(*block, *region, *cell)
+		}
+		if err != nil {
+			fmt.Println(err)
 		}
 	}
+	fromFile := *input != ""
+	// a document is processed from a file into a file, a dry run only needs the input file
+	validArgs := flags.NArg() == 1 && *output == ""
+	if fromFile {
+		validArgs = flags.NArg() == 0 && (*output != "") != *dryRun
+	}
+	if err != nil || !validArgs {
+		fmt.Println("Usage:", os.Args[0], "[-dry-run [-json]] [-resume off|unfilled|errors] [-block name] [-region name] [-cell keychain] document_id")
+		fmt.Println("      ", os.Args[0], "[-resume off|unfilled|errors] [-block name] [-region name] [-cell keychain] -input scorecard.json -output result.json")
+		fmt.Println("      ", os.Args[0], "-dry-run [-json] -input scorecard.json")
+		fmt.Println("      ", os.Args[0], "validate [-json] document_id|file")
+		return 1
+	}
 
-	mngr, err := manager.GetManager(documentID)
+	start := time.Now()
+	if !loadEnvironmentFile() {
+		return 7
+	}
+
+	var mngr *manager.Manager
+	var store *manager.MemoryStore
+	var documentID string
+	if fromFile {
+		mngr, store, documentID, err = fileManager(*input)
+	} else {
+		documentID = flags.Arg(0)
+		mngr, err = manager.GetManager(documentID)
+	}
 	if err != nil {
 		log.Printf("manager error GetManager %q", err)
 		return 2
 	}
 
-	err = mngr.Run()
+	// an interrupt stops the directors and their in-flight queries
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *dryRun {
+		return printDryRun(ctx, mngr, *jsonReport)
+	}
+	mngr.SetResumeMode(resumeMode)
+	mngr.SetScope(scope)
+	err = mngr.Run(ctx)
+	if fromFile {
+		// the document is written even if the run failed, its status and results show how far it got
+		if writeErr := store.WriteFile(documentID, *output); writeErr != nil {
+			log.Printf("error writing the processed document %q", writeErr)
+			return 6
+		}
+	}
+	var partial *manager.PartialError
+	if errors.As(err, &partial) {
+		// the other regions were processed and written to the document
+		log.Printf("manager run partly failed %q", err)
+		return 9
+	}
 	if err != nil {
 		log.Printf("manager test run error %q", err)
 		return 6
@@ -56,3 +133,53 @@ func process() int {
 	fmt.Printf("Took %s seconds", elapsed)
 	return 0
 }
+
+// fileManager returns a manager for the scorecard document in the file. The document is processed in memory
+// against the configured data source, nothing is read from or written to couchbase and the scorecard app isn't notified.
+func fileManager(fileName string) (*manager.Manager, *manager.MemoryStore, string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var doc struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, "", fmt.Errorf("error reading the id of %s: %w", fileName, err)
+	}
+	mngr, err := manager.GetManager(doc.ID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	store := manager.NewMemoryStore()
+	if err := store.Put(doc.ID, data); err != nil {
+		return nil, nil, "", err
+	}
+	mngr.SetDocumentStore(store)
+	mngr.SetNotifyScorecardApp(false)
+	return mngr, store, doc.ID, nil
+}
+
+// printDryRun prints the dry run report of the document, it returns 8 if the report has problems
+func printDryRun(ctx context.Context, mngr *manager.Manager, jsonReport bool) int {
+	report, err := mngr.DryRun(ctx)
+	if err != nil {
+		log.Printf("manager dry run error %q", err)
+		return 6
+	}
+	if jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Printf("error writing the dry run report %q", err)
+		return 6
+	}
+	if report.ProblemCount() > 0 {
+		return 8
+	}
+	return 0
+}
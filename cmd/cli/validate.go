@@ -0,0 +1,84 @@
+package main
+
+/*
+validate a scorecard document that is in couchbase or in a file
+*/
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/manager"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// validate checks the structure of a scorecard document and prints its problems, it returns 8 if the document has problems.
+// The argument is read from a file if there is a file by that name, otherwise it is a document id.
+func validate(args []string) int {
+	flags := flag.NewFlagSet(os.Args[0]+" validate", flag.ContinueOnError)
+	jsonProblems := flags.Bool("json", false, "print the problems as JSON instead of text")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Println("Usage:", os.Args[0], "validate [-json] document_id|file")
+		return 1
+	}
+	problems, err := documentProblems(flags.Arg(0))
+	if err != nil {
+		log.Printf("validate error %q", err)
+		return 6
+	}
+	if *jsonProblems {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(problems)
+	} else {
+		err = writeProblems(problems)
+	}
+	if err != nil {
+		log.Printf("error writing the problems %q", err)
+		return 6
+	}
+	if len(problems) > 0 {
+		return 8
+	}
+	return 0
+}
+
+// documentProblems validates the document in the file or with the id
+func documentProblems(fileOrID string) ([]scorecard.Problem, error) {
+	if _, err := os.Stat(fileOrID); err == nil {
+		data, err := os.ReadFile(fileOrID)
+		if err != nil {
+			return nil, err
+		}
+		return scorecard.Validate(data), nil
+	}
+	if !loadEnvironmentFile() {
+		return nil, fmt.Errorf("couldn't load the environment")
+	}
+	mngr, err := manager.GetManager(fileOrID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return mngr.Validate(ctx)
+}
+
+func writeProblems(problems []scorecard.Problem) error {
+	if len(problems) == 0 {
+		_, err := fmt.Println("The document is valid")
+		return err
+	}
+	for _, p := range problems {
+		if _, err := fmt.Println(p); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Printf("The document has %d problems\n", len(problems))
+	return err
+}
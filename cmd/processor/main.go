@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -13,7 +14,34 @@ import (
 // ProcessorFactory is a wrapper function to satisfy the requirements of
 // Worker and to keep the api package ignorant of the manager package
 func processorFactory(docID string) (api.Processor, error) {
-	return manager.GetManager(docID)
+	mngr, err := manager.GetManager(docID)
+	if err != nil {
+		return nil, err
+	}
+	return processor{mngr}, nil
+}
+
+// processor adapts a manager.Manager to the api.DryRunner, api.Validator, api.FindingsReporter,
+// api.ProgressReporter and api.Scoper interfaces
+type processor struct {
+	*manager.Manager
+}
+
+func (p processor) DryRun(ctx context.Context) (any, error) {
+	report, err := p.Manager.DryRun(ctx)
+	if err != nil {
+		// not a nil *manager.DryRunReport in a non-nil any
+		return nil, err
+	}
+	return report, nil
+}
+
+func (p processor) Validate(ctx context.Context) (any, error) {
+	problems, err := p.Manager.Validate(ctx)
+	if err != nil || len(problems) == 0 {
+		return nil, err
+	}
+	return problems, nil
 }
 
 func main() {
@@ -51,3 +79,26 @@ func main() {
 		panic(err)
 	}
 }
+
+func (p processor) Findings() any {
+	findings := p.Manager.QueryCostFindings()
+	if len(findings) == 0 {
+		return nil
+	}
+	return findings
+}
+
+func (p processor) SetProgressListener(listener func(progress any)) {
+	p.Manager.SetProgressListener(func(progress manager.Progress) {
+		listener(progress)
+	})
+}
+
+func (p processor) SetScope(block, region, cell string) error {
+	scope, err := manager.ParseScope(block, region, cell)
+	if err != nil {
+		return err
+	}
+	p.Manager.SetScope(scope)
+	return nil
+}
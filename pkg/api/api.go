@@ -2,6 +2,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -21,6 +23,7 @@ func SetupRouter(js *jobstore.JobStore) *gin.Engine {
 	router.POST("/jobs/", server.createJobHandler)
 	router.GET("/jobs/", server.getAllJobsHandler)
 	router.GET("/jobs/:id", server.getJobHandler)
+	router.DELETE("/jobs/:id", server.cancelJobHandler)
 	router.GET(defaultMetricPath, gin.WrapH(promhttp.Handler())) // expose Prometheus metrics
 
 	// healthcheck
@@ -35,8 +38,47 @@ func SetupRouter(js *jobstore.JobStore) *gin.Engine {
 
 // Processor is an interface used to inject calculation functions into the Worker
 // processor is intended to encapsulate the manager.manager struct
+// the context is cancelled when the job is cancelled through the API
 type Processor interface {
-	Run() error
+	Run(ctx context.Context) error
+}
+
+// DryRunner is implemented by Processors that support dry run Jobs. DryRun checks the
+// document without processing it and the report is returned with the Job.
+type DryRunner interface {
+	DryRun(ctx context.Context) (report any, err error)
+}
+
+// Validator is implemented by Processors that can check the document of a Job before it is processed.
+// A Job whose document has problems is rejected and the problems are returned with the Job, a Validator
+// returns nil problems for a valid document.
+type Validator interface {
+	Validate(ctx context.Context) (problems any, err error)
+}
+
+// FindingsReporter is implemented by Processors that have findings about a Job after it ran,
+// e.g. the expensive queries of a scorecard. They are returned with the Job.
+type FindingsReporter interface {
+	Findings() any
+}
+
+// ProgressReporter is implemented by Processors that report the progress of a Job while it runs.
+// The Worker sets a listener that is called with the progress, the listener isn't called after Run returns.
+type ProgressReporter interface {
+	SetProgressListener(listener func(progress any))
+}
+
+// Scoper is implemented by Processors that can process a part of the document, for Jobs with a scope.
+// SetScope is called before the Job is validated and run, it returns an error for a scope that doesn't parse.
+type Scoper interface {
+	SetScope(block, region, cell string) error
+}
+
+// PartialError is returned by the Run of a Processor that processed the document except for some parts
+// of it. The Job is "partial" and has the errors of those parts.
+type PartialError interface {
+	error
+	PartialErrors() any
 }
 
 // Worker receives jobs on a channel, processes them, and reports the status on a return channel
@@ -51,23 +93,64 @@ func Worker(id int, getProcessor func(string) (Processor, error), jobs <-chan jo
 		start := time.Now()
 		fmt.Println("Worker", id, "processing docID", job.DocID)
 
+		ctx := runningJobs.start(job.ID)
 		mgr, err := getProcessor(job.DocID)
 		if err != nil {
+			runningJobs.finish(job.ID)
 			fmt.Printf("Error: Job %v - %v\n", job.DocID, err)
 			job.Status = jobstore.StatusFailed
 			status <- job
 			continue
 		}
 
-		err = mgr.Run()
+		if job.Scope != nil {
+			err = setScope(mgr, *job.Scope)
+		}
+		switch {
+		case err != nil:
+			// the scope of the job doesn't parse, the job fails without running
+		case job.DryRun:
+			job.Report, err = dryRun(ctx, mgr)
+		default:
+			job.Problems, err = validate(ctx, mgr)
+			if err == nil && job.Problems == nil {
+				lastProgress := reportProgress(mgr, job, status)
+				err = mgr.Run(ctx)
+				job.Progress = lastProgress()
+			}
+		}
+		cancelled := runningJobs.finish(job.ID)
+		if reporter, ok := mgr.(FindingsReporter); ok {
+			job.Findings = reporter.Findings()
+		}
 		duration := time.Since(start).Seconds()
 		calculationDuration.WithLabelValues(job.DocID).Observe(duration)
+		if err != nil && cancelled {
+			fmt.Printf("Cancelled: Job %v - %v\n", job.DocID, err)
+			job.Status = jobstore.StatusCancelled
+			status <- job
+			continue
+		}
+		var partial PartialError
+		if errors.As(err, &partial) {
+			fmt.Printf("Partial: Job %v - %v\n", job.DocID, err)
+			job.Errors = partial.PartialErrors()
+			job.Status = jobstore.StatusPartial
+			status <- job
+			continue
+		}
 		if err != nil {
 			fmt.Printf("Error: Job %v - %v\n", job.DocID, err)
 			job.Status = jobstore.StatusFailed
 			status <- job
 			continue
 		}
+		if job.Problems != nil {
+			fmt.Printf("Rejected: Job %v - the document is invalid\n", job.DocID)
+			job.Status = jobstore.StatusRejected
+			status <- job
+			continue
+		}
 
 		// report status
 		job.Status = jobstore.StatusCompleted
@@ -76,6 +159,50 @@ func Worker(id int, getProcessor func(string) (Processor, error), jobs <-chan jo
 	}
 }
 
+// dryRun runs the DryRun of a Processor that supports it
+func dryRun(ctx context.Context, mgr Processor) (any, error) {
+	dryRunner, ok := mgr.(DryRunner)
+	if !ok {
+		return nil, fmt.Errorf("api Worker error: the processor doesn't support dry runs")
+	}
+	return dryRunner.DryRun(ctx)
+}
+
+// setScope limits a Processor that supports it to the scope of a Job
+func setScope(mgr Processor, scope jobstore.Scope) error {
+	scoper, ok := mgr.(Scoper)
+	if !ok {
+		return fmt.Errorf("api Worker error: the processor doesn't support scoped jobs")
+	}
+	return scoper.SetScope(scope.Block, scope.Region, scope.Cell)
+}
+
+// reportProgress makes a ProgressReporter send the progress of the job on the status channel while it runs.
+// The returned function returns the last progress once Run has returned.
+func reportProgress(mgr Processor, job jobstore.Job, status chan<- jobstore.Job) (lastProgress func() any) {
+	reporter, ok := mgr.(ProgressReporter)
+	if !ok {
+		return func() any { return nil }
+	}
+	var progress any
+	reporter.SetProgressListener(func(p any) {
+		progress = p
+		update := job // the job is processing
+		update.Progress = p
+		status <- update
+	})
+	return func() any { return progress }
+}
+
+// validate checks the document of a Job if the Processor supports it
+func validate(ctx context.Context, mgr Processor) (any, error) {
+	validator, ok := mgr.(Validator)
+	if !ok {
+		return nil, nil
+	}
+	return validator.Validate(ctx)
+}
+
 // Dispatch pulls jobs out of the given jobstore in order and places them in a channel. It will block once the channel is full.
 func Dispatch(jobChan chan<- jobstore.Job, js *jobstore.JobStore) {
 	for {
@@ -99,6 +226,40 @@ func Dispatch(jobChan chan<- jobstore.Job, js *jobstore.JobStore) {
 func StatusUpdater(statusChan <-chan jobstore.Job, js *jobstore.JobStore) {
 	for {
 		job := <-statusChan
+		if job.Report != nil {
+			err := js.UpdateJobReport(job.ID, job.Report)
+			if err != nil {
+				fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
+			}
+		}
+		if job.Findings != nil {
+			err := js.UpdateJobFindings(job.ID, job.Findings)
+			if err != nil {
+				fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
+			}
+		}
+		if job.Problems != nil {
+			err := js.UpdateJobProblems(job.ID, job.Problems)
+			if err != nil {
+				fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
+			}
+		}
+		if job.Errors != nil {
+			err := js.UpdateJobErrors(job.ID, job.Errors)
+			if err != nil {
+				fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
+			}
+		}
+		if job.Progress != nil {
+			err := js.UpdateJobProgress(job.ID, job.Progress)
+			if err != nil {
+				fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
+			}
+			if job.Status == jobstore.StatusProcessing {
+				// a progress update doesn't change the status, the job may have been cancelled meanwhile
+				continue
+			}
+		}
 		err := js.UpdateJobStatus(job.ID, job.Status)
 		if err != nil {
 			fmt.Printf("Error - StatusUpdater: %v\n", err.Error())
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -20,20 +21,88 @@ type TestProcess struct {
 	DocID        string
 	Processed    bool
 	TriggerError bool
+	Block        bool // run until the context is cancelled
+	Invalid      bool // the document has problems
+	Progress     bool // report the progress while running
+	Partial      bool // a part of the document fails
+	listener     func(progress any)
+	Scope        []string // the block, region and cell of a scoped job
 }
 
 // Run is a dummy method for testing that satisfies the Processor interface
-func (tp *TestProcess) Run() error {
+func (tp *TestProcess) Run(ctx context.Context) error {
 	tp.lock.Lock()
 	defer tp.lock.Unlock()
+	if tp.Block {
+		<-ctx.Done()
+		return fmt.Errorf("TestProcess - Stopped %v: %w", tp.DocID, ctx.Err())
+	}
 	if tp.TriggerError {
 		return fmt.Errorf("TestProcess - Unable to process %v", tp.DocID)
 	}
+	if tp.Partial {
+		return fmt.Errorf("TestProcess - Partly processed %v: %w", tp.DocID, testPartialError{"Block1"})
+	}
+	if tp.Progress && tp.listener != nil {
+		tp.listener(map[string]any{"percent": 50.0})
+		tp.listener(map[string]any{"percent": 100.0})
+	}
 	fmt.Println("TestProcess - Processed", tp.DocID)
 	tp.Processed = true
 	return nil
 }
 
+// testPartialError satisfies the PartialError interface
+type testPartialError struct {
+	block string
+}
+
+func (e testPartialError) Error() string {
+	return e.block + " failed"
+}
+
+func (e testPartialError) PartialErrors() any {
+	return []string{e.Error()}
+}
+
+// DryRun is a dummy method for testing that satisfies the DryRunner interface
+func (tp *TestProcess) DryRun(ctx context.Context) (any, error) {
+	if tp.TriggerError {
+		return nil, fmt.Errorf("TestProcess - Unable to dry run %v", tp.DocID)
+	}
+	return map[string]any{"docid": tp.DocID, "cells": 2}, nil
+}
+
+// Validate is a dummy method for testing that satisfies the Validator interface
+func (tp *TestProcess) Validate(ctx context.Context) (any, error) {
+	if tp.Invalid {
+		return []string{"$.plotParams: is missing"}, nil
+	}
+	return nil, nil
+}
+
+// Findings is a dummy method for testing that satisfies the FindingsReporter interface
+func (tp *TestProcess) Findings() any {
+	if tp.TriggerError {
+		return []string{"expensive query"}
+	}
+	return nil
+}
+
+// SetProgressListener is a dummy method for testing that satisfies the ProgressReporter interface
+func (tp *TestProcess) SetProgressListener(listener func(progress any)) {
+	tp.listener = listener
+}
+
+// SetScope is a dummy method for testing that satisfies the Scoper interface, a cell without a region doesn't parse
+func (tp *TestProcess) SetScope(block, region, cell string) error {
+	if cell != "" && region == "" {
+		return fmt.Errorf("TestProcess - the cell %q has no region", cell)
+	}
+	tp.Scope = []string{block, region, cell}
+	return nil
+}
+
 // Close is a dummy method for testing that satisfies the Processor interface
 func (tp *TestProcess) Close() error {
 	return nil
@@ -46,6 +115,14 @@ func ProcessorFactoryMock(docID string) (Processor, error) {
 		return &TestProcess{DocID: docID}, nil
 	case "Err":
 		return &TestProcess{DocID: docID, TriggerError: true}, nil
+	case "Block":
+		return &TestProcess{DocID: docID, Block: true}, nil
+	case "Invalid":
+		return &TestProcess{DocID: docID, Invalid: true}, nil
+	case "Progress":
+		return &TestProcess{DocID: docID, Progress: true}, nil
+	case "Partial":
+		return &TestProcess{DocID: docID, Partial: true}, nil
 	default:
 		return nil, fmt.Errorf("Unknown processor type")
 	}
@@ -184,7 +261,7 @@ func TestWorker(t *testing.T) {
 
 		want := []jobstore.Job{
 			{ID: 1, DocID: "Err:foo", Status: jobstore.StatusProcessing},
-			{ID: 1, DocID: "Err:foo", Status: jobstore.StatusFailed},
+			{ID: 1, DocID: "Err:foo", Status: jobstore.StatusFailed, Findings: []string{"expensive query"}},
 		}
 		for {
 			select {
@@ -200,6 +277,130 @@ func TestWorker(t *testing.T) {
 	})
 }
 
+func TestWorkerDryRun(t *testing.T) {
+	jobs := make(chan jobstore.Job)
+	status := make(chan jobstore.Job)
+	go Worker(5, ProcessorFactoryMock, jobs, status)
+
+	jobs <- jobstore.Job{ID: 201, DocID: "SC:foo", DryRun: true}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	want := jobstore.Job{ID: 201, DocID: "SC:foo", Status: jobstore.StatusCompleted, DryRun: true, Report: map[string]any{"docid": "SC:foo", "cells": 2}}
+	assert.Equal(t, want, <-status)
+
+	jobs <- jobstore.Job{ID: 202, DocID: "Err:foo", DryRun: true}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	assert.Equal(t, jobstore.Job{ID: 202, DocID: "Err:foo", Status: jobstore.StatusFailed, DryRun: true, Findings: []string{"expensive query"}}, <-status)
+}
+
+func TestWorkerScope(t *testing.T) {
+	jobs := make(chan jobstore.Job)
+	status := make(chan jobstore.Job)
+	var processors []*TestProcess
+	go Worker(9, func(docID string) (Processor, error) {
+		processor := &TestProcess{DocID: docID}
+		processors = append(processors, processor)
+		return processor, nil
+	}, jobs, status)
+
+	scope := &jobstore.Scope{Block: "Block0", Region: "All HRRR domain"}
+	jobs <- jobstore.Job{ID: 501, DocID: "SC:foo", Scope: scope}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	assert.Equal(t, jobstore.Job{ID: 501, DocID: "SC:foo", Status: jobstore.StatusCompleted, Scope: scope}, <-status)
+	assert.Equal(t, []string{"Block0", "All HRRR domain", ""}, processors[0].Scope)
+	assert.True(t, processors[0].Processed)
+
+	// a scope that doesn't parse fails the job without running it
+	jobs <- jobstore.Job{ID: 502, DocID: "SC:foo", Scope: &jobstore.Scope{Cell: "RMSE"}}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	assert.Equal(t, jobstore.StatusFailed, (<-status).Status)
+	assert.False(t, processors[1].Processed)
+}
+
+func TestWorkerRejected(t *testing.T) {
+	jobs := make(chan jobstore.Job)
+	status := make(chan jobstore.Job)
+	go Worker(6, ProcessorFactoryMock, jobs, status)
+
+	jobs <- jobstore.Job{ID: 301, DocID: "Invalid:foo"}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	want := jobstore.Job{ID: 301, DocID: "Invalid:foo", Status: jobstore.StatusRejected, Problems: []string{"$.plotParams: is missing"}}
+	assert.Equal(t, want, <-status)
+
+	// a dry run reports the problems of the document itself
+	jobs <- jobstore.Job{ID: 302, DocID: "Invalid:foo", DryRun: true}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	assert.Equal(t, jobstore.StatusCompleted, (<-status).Status)
+}
+
+func TestWorkerPartial(t *testing.T) {
+	jobs := make(chan jobstore.Job)
+	status := make(chan jobstore.Job)
+	go Worker(10, ProcessorFactoryMock, jobs, status)
+
+	jobs <- jobstore.Job{ID: 601, DocID: "Partial:foo"}
+	assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+	want := jobstore.Job{ID: 601, DocID: "Partial:foo", Status: jobstore.StatusPartial, Errors: []string{"Block1 failed"}}
+	assert.Equal(t, want, <-status)
+}
+
+func TestWorkerProgress(t *testing.T) {
+	jobs := make(chan jobstore.Job)
+	status := make(chan jobstore.Job)
+	go Worker(7, ProcessorFactoryMock, jobs, status)
+
+	jobs <- jobstore.Job{ID: 401, DocID: "Progress:foo"}
+	assert.Equal(t, jobstore.Job{ID: 401, DocID: "Progress:foo", Status: jobstore.StatusProcessing}, <-status)
+	for _, percent := range []float64{50, 100} {
+		want := jobstore.Job{ID: 401, DocID: "Progress:foo", Status: jobstore.StatusProcessing, Progress: map[string]any{"percent": percent}}
+		assert.Equal(t, want, <-status)
+	}
+	// the finished job has the last progress
+	want := jobstore.Job{ID: 401, DocID: "Progress:foo", Status: jobstore.StatusCompleted, Progress: map[string]any{"percent": 100.0}}
+	assert.Equal(t, want, <-status)
+}
+
+func TestStatusUpdaterProgress(t *testing.T) {
+	js := jobstore.NewJobStore()
+	_, _ = js.CreateJob("SC:foo")
+	status := make(chan jobstore.Job)
+	go StatusUpdater(status, js)
+
+	status <- jobstore.Job{ID: 0, DocID: "SC:foo", Status: jobstore.StatusProcessing}
+	status <- jobstore.Job{ID: 0, DocID: "SC:foo", Status: jobstore.StatusProcessing, Progress: 50.0}
+	// a progress update doesn't undo a status that was set meanwhile
+	_ = js.UpdateJobStatus(0, jobstore.StatusCancelled)
+	status <- jobstore.Job{ID: 0, DocID: "SC:foo", Status: jobstore.StatusProcessing, Progress: 75.0}
+	status <- jobstore.Job{ID: 1} // waits for the progress update to be stored
+	got, _ := js.GetJob(0)
+	assert.Equal(t, jobstore.Job{ID: 0, DocID: "SC:foo", Status: jobstore.StatusCancelled, Progress: 75.0}, got)
+}
+
+func TestWorkerCancel(t *testing.T) {
+	t.Run("Test that a processing job is cancelled", func(t *testing.T) {
+		jobs := make(chan jobstore.Job)
+		status := make(chan jobstore.Job)
+		job := jobstore.Job{ID: 101, DocID: "Block:foo", Status: jobstore.StatusCreated}
+		go Worker(3, ProcessorFactoryMock, jobs, status)
+		jobs <- job
+
+		assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+		runningJobs.cancel(job.ID)
+		assert.Equal(t, jobstore.Job{ID: 101, DocID: "Block:foo", Status: jobstore.StatusCancelled}, <-status)
+	})
+
+	t.Run("Test that a job cancelled before it starts is stopped", func(t *testing.T) {
+		jobs := make(chan jobstore.Job)
+		status := make(chan jobstore.Job)
+		job := jobstore.Job{ID: 102, DocID: "Block:bar", Status: jobstore.StatusCreated}
+		runningJobs.cancel(job.ID)
+		go Worker(4, ProcessorFactoryMock, jobs, status)
+		jobs <- job
+
+		assert.Equal(t, jobstore.StatusProcessing, (<-status).Status)
+		assert.Equal(t, jobstore.StatusCancelled, (<-status).Status)
+	})
+}
+
 func TestDispatch(t *testing.T) {
 	t.Run("Test that jobs are dispatched", func(t *testing.T) {
 		jobs := make(chan jobstore.Job)
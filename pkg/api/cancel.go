@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errJobCancelled is the cause of the context of a job that was cancelled through the API
+var errJobCancelled = errors.New("job cancelled by request")
+
+// jobCancels keeps the cancel functions of the running jobs so that the API can stop them.
+// A job that is cancelled before a Worker starts it is remembered, and is stopped as soon as it starts.
+type jobCancels struct {
+	lock      sync.Mutex
+	cancels   map[int]context.CancelCauseFunc
+	cancelled map[int]bool
+}
+
+// runningJobs is shared by the Workers and the jobServer
+var runningJobs = newJobCancels()
+
+func newJobCancels() *jobCancels {
+	return &jobCancels{
+		cancels:   make(map[int]context.CancelCauseFunc),
+		cancelled: make(map[int]bool),
+	}
+}
+
+// start returns the context for running the job with the given id
+func (jc *jobCancels) start(id int) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	jc.lock.Lock()
+	defer jc.lock.Unlock()
+	jc.cancels[id] = cancel
+	if jc.cancelled[id] {
+		cancel(errJobCancelled)
+	}
+	return ctx
+}
+
+// finish releases the context of the job and reports if it was cancelled through the API
+func (jc *jobCancels) finish(id int) (cancelled bool) {
+	jc.lock.Lock()
+	defer jc.lock.Unlock()
+	if cancel, ok := jc.cancels[id]; ok {
+		cancel(nil)
+	}
+	cancelled = jc.cancelled[id]
+	delete(jc.cancels, id)
+	delete(jc.cancelled, id)
+	return cancelled
+}
+
+// cancel stops the job with the given id, or marks it so that it stops as soon as it starts
+func (jc *jobCancels) cancel(id int) {
+	jc.lock.Lock()
+	defer jc.lock.Unlock()
+	jc.cancelled[id] = true
+	if cancel, ok := jc.cancels[id]; ok {
+		cancel(errJobCancelled)
+	}
+}
@@ -46,7 +46,10 @@ func isValidDocType(docType string) bool {
 // createJobHandler handles requests to create a new Job in the store
 func (js *jobServer) createJobHandler(c *gin.Context) {
 	type RequestJob struct {
-		DocID string `json:"docid" binding:"required"`
+		DocID  string `json:"docid" binding:"required"`
+		DryRun bool   `json:"dry_run"` // check the queries of the document without processing it
+		// only process a block, a region or the cells below a keychain of the document
+		Scope *jobstore.Scope `json:"scope"`
 	}
 
 	var rj RequestJob
@@ -69,7 +72,22 @@ func (js *jobServer) createJobHandler(c *gin.Context) {
 		return
 	}
 
-	id, err := js.store.CreateJob(rj.DocID)
+	if rj.Scope != nil && (rj.DryRun || *rj.Scope == (jobstore.Scope{})) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": "A scope needs a block, a region or a cell and a dry run can't have one",
+		})
+		return
+	}
+
+	createJob := js.store.CreateJob
+	if rj.DryRun {
+		createJob = js.store.CreateDryRunJob
+	}
+	if rj.Scope != nil {
+		createJob = func(docID string) (int, error) { return js.store.CreateScopedJob(docID, *rj.Scope) }
+	}
+	id, err := createJob(rj.DocID)
 	if err != nil {
 		if err.Error() == "docID already exists" {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -78,6 +96,13 @@ func (js *jobServer) createJobHandler(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "docID is being processed" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": "That docid is being processed",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    http.StatusInternalServerError,
 			"message": err.Error(),
@@ -109,3 +134,43 @@ func (js *jobServer) getJobHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, job)
 }
+
+// cancelJobHandler handles requests to cancel a specific Job. A processing Job is stopped
+// and a Job that hasn't started yet is stopped as soon as a Worker picks it up.
+func (js *jobServer) cancelJobHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Params.ByName("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": fmt.Sprintf("Unable to parse job id \"%v\", an int is required", c.Params.ByName("id")),
+		})
+		return
+	}
+
+	job, err := js.store.GetJob(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	switch job.Status {
+	case jobstore.StatusCompleted, jobstore.StatusFailed, jobstore.StatusCancelled:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"message": fmt.Sprintf("Job %v has already %v", id, job.Status),
+		})
+		return
+	case jobstore.StatusPartial, jobstore.StatusRejected:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"message": fmt.Sprintf("Job %v has already finished as %v", id, job.Status),
+		})
+		return
+	}
+
+	runningJobs.cancel(id)
+	c.JSON(http.StatusAccepted, gin.H{"id": id})
+}
@@ -138,6 +138,90 @@ func Test_jobServer_createJobHandler(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Equal(t, want, got)
 	})
+
+	t.Run("Test a dry run job submission for an existing docid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		jsonStr := []byte(`{"docid": "SC:json", "dry_run": true}`)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/jobs/", bytes.NewBuffer(jsonStr))
+
+		store := jobstore.NewJobStore()
+		_, _ = store.CreateJob("SC:json")
+		js := newJobServer(store)
+
+		js.createJobHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"id":1}`, w.Body.String())
+		job, _ := store.GetJob(1)
+		assert.Equal(t, jobstore.Job{ID: 1, DocID: "SC:json", Status: jobstore.StatusCreated, DryRun: true}, job)
+	})
+
+	scopeTests := []struct {
+		name       string
+		body       string
+		status     jobstore.JobStatus // of the existing job of the docid
+		wantCode   int
+		wantResult string
+	}{
+		{
+			name:       "Test a scoped job submission for a processed docid",
+			body:       `{"docid": "SC:json", "scope": {"block": "Block0", "cell": "All HRRR domain -> RMSE"}}`,
+			status:     jobstore.StatusCompleted,
+			wantCode:   http.StatusOK,
+			wantResult: `{"id":1}`,
+		},
+		{
+			name:       "Test a scoped job submission for a docid that is processing",
+			body:       `{"docid": "SC:json", "scope": {"block": "Block0"}}`,
+			status:     jobstore.StatusProcessing,
+			wantCode:   http.StatusBadRequest,
+			wantResult: `{"code":400,"message":"That docid is being processed"}`,
+		},
+		{
+			name:       "Test an empty scope",
+			body:       `{"docid": "SC:json", "scope": {}}`,
+			status:     jobstore.StatusCompleted,
+			wantCode:   http.StatusBadRequest,
+			wantResult: `{"code":400,"message":"A scope needs a block, a region or a cell and a dry run can't have one"}`,
+		},
+		{
+			name:       "Test a scoped dry run",
+			body:       `{"docid": "SC:json", "dry_run": true, "scope": {"block": "Block0"}}`,
+			status:     jobstore.StatusCompleted,
+			wantCode:   http.StatusBadRequest,
+			wantResult: `{"code":400,"message":"A scope needs a block, a region or a cell and a dry run can't have one"}`,
+		},
+	}
+	for _, tt := range scopeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/jobs/", bytes.NewBufferString(tt.body))
+
+			store := jobstore.NewJobStore()
+			id, _ := store.CreateJob("SC:json")
+			if tt.status != jobstore.StatusCreated {
+				_ = store.UpdateJobStatus(id, jobstore.StatusProcessing)
+			}
+			if tt.status == jobstore.StatusCompleted {
+				_ = store.UpdateJobStatus(id, jobstore.StatusCompleted)
+			}
+			js := newJobServer(store)
+
+			js.createJobHandler(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.JSONEq(t, tt.wantResult, w.Body.String())
+		})
+	}
+	t.Run("Test the scope of a scoped job", func(t *testing.T) {
+		store := jobstore.NewJobStore()
+		id, err := store.CreateScopedJob("SC:json", jobstore.Scope{Block: "Block0"})
+		assert.Nil(t, err)
+		job, _ := store.GetJob(id)
+		assert.Equal(t, jobstore.Job{ID: 0, DocID: "SC:json", Status: jobstore.StatusCreated, Scope: &jobstore.Scope{Block: "Block0"}}, job)
+	})
 }
 
 func Test_jobServer_getJobHandler(t *testing.T) {
@@ -212,3 +296,43 @@ func Test_jobServer_getJobHandler(t *testing.T) {
 		assert.Equal(t, want, got)
 	})
 }
+
+func Test_jobServer_cancelJobHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		status   jobstore.JobStatus
+		wantCode int
+		wantBody string
+	}{
+		{name: "Test cancelling a created job", id: "0", status: jobstore.StatusCreated, wantCode: http.StatusAccepted, wantBody: `{"id":0}`},
+		{name: "Test cancelling a processing job", id: "0", status: jobstore.StatusProcessing, wantCode: http.StatusAccepted, wantBody: `{"id":0}`},
+		{name: "Test cancelling a completed job", id: "0", status: jobstore.StatusCompleted, wantCode: http.StatusConflict, wantBody: `{"code":409,"message":"Job 0 has already completed"}`},
+		{name: "Test cancelling a partial job", id: "0", status: jobstore.StatusPartial, wantCode: http.StatusConflict, wantBody: `{"code":409,"message":"Job 0 has already finished as partial"}`},
+		{name: "Test cancelling a rejected job", id: "0", status: jobstore.StatusRejected, wantCode: http.StatusConflict, wantBody: `{"code":409,"message":"Job 0 has already finished as rejected"}`},
+		{name: "Test an invalid job", id: "3", status: jobstore.StatusCreated, wantCode: http.StatusNotFound, wantBody: `{"code":404,"message":"job with id=3 not found"}`},
+		{name: "Test an invalid request", id: "stuff", status: jobstore.StatusCreated, wantCode: http.StatusBadRequest, wantBody: `{"code":400,"message":"Unable to parse job id \"stuff\", an int is required"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodDelete, "/jobs/"+tt.id, http.NoBody)
+			c.Params = []gin.Param{{Key: "id", Value: tt.id}}
+			js := newJobServer(nil)
+			id, _ := js.store.CreateJob("foo")
+			if tt.status != jobstore.StatusCreated {
+				_ = js.store.UpdateJobStatus(id, tt.status)
+			}
+			defer runningJobs.finish(id)
+
+			js.cancelJobHandler(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			if tt.wantCode == http.StatusConflict {
+				assert.False(t, runningJobs.finish(id), "a finished job must not be marked as cancelled")
+			}
+		})
+	}
+}
@@ -24,11 +24,14 @@ const (
 	StatusProcessing
 	StatusCompleted
 	StatusFailed
+	StatusCancelled
+	StatusRejected
+	StatusPartial
 )
 
 // String supports pretty-printing JobStatuses
 func (js JobStatus) String() string {
-	return []string{"created", "processing", "completed", "failed"}[js]
+	return []string{"created", "processing", "completed", "failed", "cancelled", "rejected", "partial"}[js]
 }
 
 // toString is an internal helper function for marshalling to JSON
@@ -37,6 +40,9 @@ var toString = map[JobStatus]string{
 	StatusProcessing: "processing",
 	StatusCompleted:  "completed",
 	StatusFailed:     "failed",
+	StatusCancelled:  "cancelled",
+	StatusRejected:   "rejected",
+	StatusPartial:    "partial",
 }
 
 // toID is an internal helper function for unmarshalling from JSON
@@ -45,6 +51,9 @@ var toID = map[string]JobStatus{
 	"processing": StatusProcessing,
 	"completed":  StatusCompleted,
 	"failed":     StatusFailed,
+	"cancelled":  StatusCancelled,
+	"rejected":   StatusRejected,
+	"partial":    StatusPartial,
 }
 
 // MarshalJSON supports writing the iota to JSON as a string
@@ -69,7 +78,26 @@ func (js *JobStatus) UnmarshalJSON(b []byte) error {
 type Job struct {
 	ID     int       `json:"id"`
 	DocID  string    `json:"docid"`
-	Status JobStatus `json:"status"` // Zero Value is "StatusCreated"
+	Status JobStatus `json:"status"`            // Zero Value is "StatusCreated"
+	DryRun bool      `json:"dry_run,omitempty"` // only check the queries of the document, see manager.DryRun
+	Report any       `json:"report,omitempty"`  // the dry run report, once the Job is completed
+	// findings about the Job, like the expensive queries of the query cost pre-flight
+	Findings any `json:"findings,omitempty"`
+	// the problems with the document of a rejected Job
+	Problems any `json:"problems,omitempty"`
+	// the progress of a Job that is processing, or the final progress of a finished Job
+	Progress any `json:"progress,omitempty"`
+	// the part of the document that the Job processes, nil is the whole document
+	Scope *Scope `json:"scope,omitempty"`
+	// the errors of the parts of the document that a partial Job couldn't process
+	Errors any `json:"errors,omitempty"`
+}
+
+// Scope limits a Job to a block, a region or the cells below a keychain of the document, see manager.Scope
+type Scope struct {
+	Block  string `json:"block,omitempty"`
+	Region string `json:"region,omitempty"`
+	Cell   string `json:"cell,omitempty"` // in the builder GetPath format e.g. "All HRRR domain -> RMSE"
 }
 
 // FIXME - we'll want to handle removing Jobs from the JobStore so we don't
@@ -96,6 +124,24 @@ func NewJobStore() *JobStore {
 
 // CreateJob creates a new job in the store and returns the int key to access it
 func (js *JobStore) CreateJob(docID string) (int, error) {
+	return js.createJob(docID, false, nil)
+}
+
+// CreateDryRunJob creates a new dry run job in the store and returns the int key to access it.
+// A dry run doesn't change the document so a docID can have any number of dry run jobs,
+// besides its one processing job.
+func (js *JobStore) CreateDryRunJob(docID string) (int, error) {
+	return js.createJob(docID, true, nil)
+}
+
+// CreateScopedJob creates a new job that only processes the scope of the document, e.g. to recompute a block
+// after its query templates were fixed. A docID can have any number of scoped jobs, but not while its
+// processing job is waiting or processing.
+func (js *JobStore) CreateScopedJob(docID string, scope Scope) (int, error) {
+	return js.createJob(docID, false, &scope)
+}
+
+func (js *JobStore) createJob(docID string, dryRun bool, scope *Scope) (int, error) {
 	js.lock.Lock()
 	defer js.lock.Unlock()
 
@@ -103,19 +149,29 @@ func (js *JobStore) CreateJob(docID string) (int, error) {
 		return 0, fmt.Errorf("expected a non-empty docID")
 	}
 
-	_, exists := js.reverseIndex[docID]
-	if exists {
+	id, exists := js.reverseIndex[docID]
+	if exists && !dryRun && scope == nil {
 		return 0, fmt.Errorf("docID already exists")
 	}
+	if exists && scope != nil {
+		status := js.jobs[id].Status
+		if status == StatusCreated || status == StatusProcessing {
+			return 0, fmt.Errorf("docID is being processed")
+		}
+	}
 
 	job := Job{
 		ID:     js.nextID,
 		DocID:  docID,
 		Status: StatusCreated,
+		DryRun: dryRun,
+		Scope:  scope,
 	}
 
 	js.jobs[js.nextID] = job
-	js.reverseIndex[docID] = js.nextID
+	if !dryRun && scope == nil {
+		js.reverseIndex[docID] = js.nextID
+	}
 	js.nextID++
 
 	// Update Prometheus metrics
@@ -202,7 +258,95 @@ func (js *JobStore) UpdateJobStatus(id int, status JobStatus) error {
 	case StatusFailed:
 		jobsProcessing.Dec()
 		jobsFailed.Inc()
+	case StatusCancelled:
+		jobsProcessing.Dec()
+		jobsCancelled.Inc()
+	case StatusRejected:
+		jobsProcessing.Dec()
+		jobsRejected.Inc()
+	case StatusPartial:
+		jobsProcessing.Dec()
+		jobsPartial.Inc()
+	}
+	return nil
+}
 
+// UpdateJobReport sets the dry run report of the Job.
+//
+// It returns an error if the Job doesn't exist.
+func (js *JobStore) UpdateJobReport(id int, report any) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id=%d not found", id)
+	}
+	job.Report = report
+	js.jobs[id] = job
+	return nil
+}
+
+// UpdateJobFindings sets the findings of the Job.
+//
+// It returns an error if the Job doesn't exist.
+func (js *JobStore) UpdateJobFindings(id int, findings any) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id=%d not found", id)
 	}
+	job.Findings = findings
+	js.jobs[id] = job
+	return nil
+}
+
+// UpdateJobProblems sets the problems with the document of the Job.
+//
+// It returns an error if the Job doesn't exist.
+func (js *JobStore) UpdateJobProblems(id int, problems any) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id=%d not found", id)
+	}
+	job.Problems = problems
+	js.jobs[id] = job
+	return nil
+}
+
+// UpdateJobErrors sets the errors of the parts of the document that the Job couldn't process.
+//
+// It returns an error if the Job doesn't exist.
+func (js *JobStore) UpdateJobErrors(id int, errors any) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id=%d not found", id)
+	}
+	job.Errors = errors
+	js.jobs[id] = job
+	return nil
+}
+
+// UpdateJobProgress sets the progress of the Job.
+//
+// It returns an error if the Job doesn't exist.
+func (js *JobStore) UpdateJobProgress(id int, progress any) error {
+	js.lock.Lock()
+	defer js.lock.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id=%d not found", id)
+	}
+	job.Progress = progress
+	js.jobs[id] = job
 	return nil
 }
@@ -1,6 +1,7 @@
 package jobstore
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -114,6 +115,45 @@ func TestJobStore_CreateJob(t *testing.T) {
 			assert.Equal(t, gotErr.Error(), wantErr)
 		}
 	})
+	t.Run("Test creating dry run jobs for a docID", func(t *testing.T) {
+		js := NewJobStore()
+
+		_, _ = js.CreateDryRunJob("foo")
+		_, _ = js.CreateDryRunJob("foo")
+		id, err := js.CreateJob("foo")
+		assert.Nil(t, err)
+		_, err = js.CreateDryRunJob("foo")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 4, len(js.jobs))
+		assert.Equal(t, Job{ID: 2, DocID: "foo", Status: StatusCreated}, js.jobs[id])
+		assert.Equal(t, Job{ID: 3, DocID: "foo", Status: StatusCreated, DryRun: true}, js.jobs[3])
+	})
+	t.Run("Test creating scoped jobs for a docID", func(t *testing.T) {
+		js := NewJobStore()
+		scope := Scope{Block: "Block0"}
+
+		_, err := js.CreateScopedJob("foo", scope)
+		assert.Nil(t, err)
+		id, err := js.CreateJob("foo")
+		assert.Nil(t, err)
+		// not while the docID is waiting to be processed or processing
+		_, err = js.CreateScopedJob("foo", scope)
+		assert.EqualError(t, err, "docID is being processed")
+		_ = js.UpdateJobStatus(id, StatusProcessing)
+		_, err = js.CreateScopedJob("foo", scope)
+		assert.EqualError(t, err, "docID is being processed")
+		_ = js.UpdateJobStatus(id, StatusCompleted)
+		_, err = js.CreateScopedJob("foo", scope)
+		assert.Nil(t, err)
+		_, err = js.CreateScopedJob("foo", scope)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 4, len(js.jobs))
+		assert.Equal(t, Job{ID: 2, DocID: "foo", Status: StatusCreated, Scope: &scope}, js.jobs[2])
+		_, err = js.CreateJob("foo")
+		assert.EqualError(t, err, "docID already exists")
+	})
 }
 
 func TestJobStore_GetJob(t *testing.T) {
@@ -328,3 +368,95 @@ func TestJobStore_updateJobStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestJobStore_UpdateJobReport(t *testing.T) {
+	js := NewJobStore()
+	_, _ = js.CreateDryRunJob("foo")
+
+	report := map[string]any{"cells": 12}
+	if err := js.UpdateJobReport(0, report); err != nil {
+		t.Fatalf("JobStore.UpdateJobReport() got an unexpected error: %v", err)
+	}
+	got, _ := js.GetJob(0)
+	assert.Equal(t, Job{ID: 0, DocID: "foo", Status: StatusCreated, DryRun: true, Report: report}, got)
+
+	if err := js.UpdateJobReport(1, report); err == nil {
+		t.Error("JobStore.UpdateJobReport() didn't error for a nonexistant job")
+	}
+}
+
+func TestJobStore_UpdateJobFindings(t *testing.T) {
+	js := NewJobStore()
+	_, _ = js.CreateJob("foo")
+
+	findings := []string{"expensive query"}
+	if err := js.UpdateJobFindings(0, findings); err != nil {
+		t.Fatalf("JobStore.UpdateJobFindings() got an unexpected error: %v", err)
+	}
+	got, _ := js.GetJob(0)
+	assert.Equal(t, Job{ID: 0, DocID: "foo", Status: StatusCreated, Findings: findings}, got)
+
+	if err := js.UpdateJobFindings(1, findings); err == nil {
+		t.Error("JobStore.UpdateJobFindings() didn't error for a nonexistant job")
+	}
+}
+
+func TestJobStore_UpdateJobProblems(t *testing.T) {
+	js := NewJobStore()
+	_, _ = js.CreateJob("foo")
+
+	problems := []string{"$.plotParams: is missing"}
+	if err := js.UpdateJobProblems(0, problems); err != nil {
+		t.Fatalf("JobStore.UpdateJobProblems() got an unexpected error: %v", err)
+	}
+	if err := js.UpdateJobStatus(0, StatusRejected); err != nil {
+		t.Fatalf("JobStore.UpdateJobStatus() got an unexpected error: %v", err)
+	}
+	got, _ := js.GetJob(0)
+	assert.Equal(t, Job{ID: 0, DocID: "foo", Status: StatusRejected, Problems: problems}, got)
+	if b, _ := json.Marshal(got.Status); string(b) != `"rejected"` {
+		t.Errorf("JobStatus.MarshalJSON() = %s, want \"rejected\"", b)
+	}
+
+	if err := js.UpdateJobProblems(1, problems); err == nil {
+		t.Error("JobStore.UpdateJobProblems() didn't error for a nonexistant job")
+	}
+}
+
+func TestJobStore_UpdateJobErrors(t *testing.T) {
+	js := NewJobStore()
+	_, _ = js.CreateJob("foo")
+
+	errors := []map[string]string{{"block": "Block0", "region": "All HRRR domain", "error": "no such table"}}
+	if err := js.UpdateJobErrors(0, errors); err != nil {
+		t.Fatalf("JobStore.UpdateJobErrors() got an unexpected error: %v", err)
+	}
+	if err := js.UpdateJobStatus(0, StatusPartial); err != nil {
+		t.Fatalf("JobStore.UpdateJobStatus() got an unexpected error: %v", err)
+	}
+	got, _ := js.GetJob(0)
+	assert.Equal(t, Job{ID: 0, DocID: "foo", Status: StatusPartial, Errors: errors}, got)
+	if b, _ := json.Marshal(got.Status); string(b) != `"partial"` {
+		t.Errorf("JobStatus.MarshalJSON() = %s, want \"partial\"", b)
+	}
+
+	if err := js.UpdateJobErrors(1, errors); err == nil {
+		t.Error("JobStore.UpdateJobErrors() didn't error for a nonexistant job")
+	}
+}
+
+func TestJobStore_UpdateJobProgress(t *testing.T) {
+	js := NewJobStore()
+	_, _ = js.CreateJob("foo")
+
+	progress := map[string]any{"percent": 50.0, "cells": 10, "cells_done": 5}
+	if err := js.UpdateJobProgress(0, progress); err != nil {
+		t.Fatalf("JobStore.UpdateJobProgress() got an unexpected error: %v", err)
+	}
+	got, _ := js.GetJob(0)
+	assert.Equal(t, Job{ID: 0, DocID: "foo", Status: StatusCreated, Progress: progress}, got)
+
+	if err := js.UpdateJobProgress(1, progress); err == nil {
+		t.Error("JobStore.UpdateJobProgress() didn't error for a nonexistant job")
+	}
+}
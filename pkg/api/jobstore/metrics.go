@@ -44,8 +44,32 @@ var (
 			Help:      "Number of jobs that have failed.",
 		},
 	)
+
+	jobsCancelled = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "jobs_cancelled",
+			Help:      "Number of jobs that were cancelled.",
+		},
+	)
+
+	jobsRejected = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "jobs_rejected",
+			Help:      "Number of jobs that were rejected because their document is invalid.",
+		},
+	)
+
+	jobsPartial = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "jobs_partial",
+			Help:      "Number of jobs that processed their document except for some parts of it.",
+		},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(jobsToBeProcessed, jobsCreated, jobsProcessing, jobsCompleted, jobsFailed)
+	prometheus.MustRegister(jobsToBeProcessed, jobsCreated, jobsProcessing, jobsCompleted, jobsFailed, jobsCancelled, jobsRejected, jobsPartial)
 }
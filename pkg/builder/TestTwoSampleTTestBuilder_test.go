@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -499,3 +500,23 @@ func TestTwoSampleTTestBuilder_test__missing_one_population(t *testing.T) {
 		t.Fatal("test_1 wrong value :", cellPtr.value)
 	}
 }
+
+// the excluded times are left out before matching and counted
+func TestTwoSampleTTestBuilder_exclusions(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	epoch := int64(1682112000)
+	var queryResult BuilderPreCalcResult
+	for i := 0; i < 10; i++ {
+		queryResult.CtlData = append(queryResult.CtlData, PreCalcRecord{Stat: float64(i%3) + 1, Avtime: epoch + int64(i)*3600})
+		queryResult.ExpData = append(queryResult.ExpData, PreCalcRecord{Stat: float64(i%4) + 2, Avtime: epoch + int64(i)*3600})
+	}
+	scc := NewTwoSampleTTestBuilder()
+	_ = scc.SetExclusions([]TimeWindow{{FromSecs: epoch + 2*3600, ToSecs: epoch + 4*3600}})
+	_, err := scc.Build(context.Background(), queryResult, RMSE, 95, 99)
+	if err != nil {
+		t.Fatal("TestTwoSampleTTestBuilder_exclusions - Build - error message : ", err)
+	}
+	if scc.GetExcluded() != 3 || len(scc.Data.CtlPop) != 7 || len(scc.Data.ExpPop) != 7 {
+		t.Errorf("TestTwoSampleTTestBuilder_exclusions excluded %d times and matched %d, want 3 and 7", scc.GetExcluded(), len(scc.Data.CtlPop))
+	}
+}
@@ -35,6 +35,7 @@ A P-value <= 0.01 (for a 99% major threshold) results in a 2. For 0.01 < P-value
 will cause a return of 0.
 */
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -43,11 +44,8 @@ import (
 	"sync"
 
 	"github.com/aclements/go-moremath/stats"
-	"github.com/go-playground/validator/v10"
 )
 
-// use a single instance of Validate, it caches struct info
-
 // setters:
 // The goodnessPolarity indicates if this population is positive good (like for TS/CSI)
 // or negative good like for RMSE or BIAS. The null hypothesis is that the populations
@@ -88,6 +86,20 @@ func (scc *ScorecardCell) SetKeyChain(keychain []string) error {
 	return nil // no errors
 }
 
+// set the exclusion windows, the valid times in them are dropped from both populations before matching
+func (scc *ScorecardCell) SetExclusions(windows []TimeWindow) error {
+	scc.exclusions = windows
+	return nil // no errors
+}
+
+// set the hour filter of the matched records, fcstLenSecs is the forecast length of the cell in seconds.
+// It is only used by the init hours of the filter, the init time of a record is its valid time minus the forecast length.
+func (scc *ScorecardCell) SetHourFilter(filter HourFilter, fcstLenSecs int64) error {
+	scc.hourFilter = filter
+	scc.fcstLenSecs = fcstLenSecs
+	return nil // no errors
+}
+
 // set the statisticType
 func (scc *ScorecardCell) SetStatisticType(statisticType StatisticType) error {
 	scc.statisticType = statisticType
@@ -208,8 +220,11 @@ func (scc *ScorecardCell) deriveInputData(qrPtr interface{}) (err error) {
 	if err != nil {
 		return err
 	}
-	// match the unmatched DataSet
+	// drop the excluded times, then match the unmatched DataSet
+	dataSet, scc.excluded = excludeTimes(dataSet, scc.exclusions)
 	matchedDataSet, err = getMatchedDataSet(dataSet)
+	// only the selected valid and init hours count, whatever the query templates select
+	matchedDataSet = filterHours(matchedDataSet, scc.hourFilter, scc.fcstLenSecs)
 	// convert matched DataSet to DerivedDataElement
 	var de DerivedDataElement
 	for i := 0; i < len(matchedDataSet.ctlPop); i++ {
@@ -292,7 +307,6 @@ func (scc *ScorecardCell) getValue() int {
 }
 
 func NewTwoSampleTTestBuilder() *ScorecardCell {
-	validate = validator.New()
 	return &ScorecardCell{mu: sync.Mutex{}}
 }
 
@@ -357,8 +371,10 @@ func (scc *ScorecardCell) GetGoodnessPolarity() GoodnessPolarity { return scc.go
 func (scc *ScorecardCell) GetMajorThreshold() Threshold          { return scc.majorThreshold }
 func (scc *ScorecardCell) GetMinorThreshold() Threshold          { return scc.minorThreshold }
 func (scc *ScorecardCell) GetStatisticType() StatisticType       { return scc.statisticType }
+func (scc *ScorecardCell) GetExcluded() int                      { return scc.excluded }
 
-func (scc *ScorecardCell) Build(qrPtr interface{}, statisticType StatisticType, minorThreshold float64, majorThreshold float64) (value int, err error) {
+// Build derives the value of the cell from the query results. It stops between the steps if ctx is done.
+func (scc *ScorecardCell) Build(ctx context.Context, qrPtr interface{}, statisticType StatisticType, minorThreshold float64, majorThreshold float64) (value int, err error) {
 	// DerivePreCalcInputData(ctlQR PreCalcRecords, expQR PreCalcRecords, statisticType string)
 	// build the input data elements and
 
@@ -382,10 +398,16 @@ func (scc *ScorecardCell) Build(qrPtr interface{}, statisticType StatisticType,
 		return ErrorValue, fmt.Errorf("mysql_director Build SetMajorThreshold error  %w", err)
 	}
 
+	if err = ctx.Err(); err != nil {
+		return ErrorValue, fmt.Errorf("mysql_director - build - stopped before SetInputData :  %w", err)
+	}
 	err = scc.deriveInputData(qrPtr)
 	if err != nil {
 		return ErrorValue, fmt.Errorf("mysql_director - build - SetInputData - error message :  %w", err)
 	}
+	if err = ctx.Err(); err != nil {
+		return ErrorValue, fmt.Errorf("mysql_director - build - stopped before ComputeSignificance :  %w", err)
+	}
 	// computes the significance for the data derived in DeriveInputData and stored in cellPtr.data
 	err = scc.computeSignificance()
 	if err != nil {
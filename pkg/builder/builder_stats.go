@@ -12,7 +12,8 @@ type DataSet struct {
 	expPop []PreCalcRecord
 }
 
-var validate *validator.Validate
+// use a single instance of Validate, it caches struct info and is safe for concurrent use
+var validate = validator.New()
 
 /*
 These are stats functions that are used to derive scorecard stats from raw populations.
@@ -23,7 +24,6 @@ There is also a time matching function. These functions are used by the builder
 func calculateStatCTC(hit float32, fa float32, miss float32, cn float32, statistic StatisticType) (float32, error) {
 	var err error
 	var value float32
-	validate = validator.New()
 	if err = validate.Var(hit, "gte=0"); err != nil {
 		value = 0
 		return value, fmt.Errorf("builder_stats calculateStatCTC %w", err)
@@ -98,6 +98,50 @@ func calculateStatScalar(squareDiffSum, NSum, obsModelDiffSum, modelSum, obsSum,
 	return value, err
 }
 
+// excludeTimes removes the records whose time is in one of the windows from both populations
+// and returns the number of distinct times that it removed
+func excludeTimes(dataSet DataSet, windows []TimeWindow) (DataSet, int) {
+	if len(windows) == 0 {
+		return dataSet, 0
+	}
+	excludedTimes := map[int64]bool{}
+	keep := func(pop []PreCalcRecord) []PreCalcRecord {
+		kept := make([]PreCalcRecord, 0, len(pop))
+		for _, record := range pop {
+			excluded := false
+			for _, window := range windows {
+				if window.Contains(record.Avtime) {
+					excluded = true
+					break
+				}
+			}
+			if excluded {
+				excludedTimes[record.Avtime] = true
+				continue
+			}
+			kept = append(kept, record)
+		}
+		return kept
+	}
+	return DataSet{ctlPop: keep(dataSet.ctlPop), expPop: keep(dataSet.expPop)}, len(excludedTimes)
+}
+
+// filterHours keeps the records of a matched dataset whose valid time the filter selects, the populations
+// stay matched because the records of both populations have the same times
+func filterHours(matched DataSet, filter HourFilter, fcstLenSecs int64) DataSet {
+	if filter.IsZero() {
+		return matched
+	}
+	var result DataSet
+	for i := range matched.ctlPop {
+		if filter.selects(matched.ctlPop[i].Avtime, fcstLenSecs) {
+			result.ctlPop = append(result.ctlPop, matched.ctlPop[i])
+			result.expPop = append(result.expPop, matched.expPop[i])
+		}
+	}
+	return result
+}
+
 // function for removing unmatched data from a dataset containing two curves
 // The intersection of the ctlData and the expData based on the time elements.
 // This function assumes that the two slices are sorted by the time element (which is an epoch)
@@ -109,6 +109,98 @@ func TestGetMatchedDataSet(t *testing.T) {
 	}
 }
 
+func Test_excludeTimes(t *testing.T) {
+	epoch := int64(1682112000)
+	tests := []struct {
+		name         string
+		args         DataSet
+		windows      []TimeWindow
+		want         DataSet
+		wantExcluded int
+	}{
+		{
+			name:    "no windows",
+			args:    getDataSet(epoch, []float64{1, 2, 3}, []float64{1, 2, 3}),
+			windows: nil,
+			want:    getDataSet(epoch, []float64{1, 2, 3}, []float64{1, 2, 3}),
+		},
+		{
+			name:         "inclusive window",
+			args:         getDataSet(epoch, []float64{1, 2, 3, 4, 5}, []float64{1, 2, 3, 4, 5}),
+			windows:      []TimeWindow{{FromSecs: epoch + 2, ToSecs: epoch + 3}},
+			want:         getDataSet(epoch, []float64{1, 4, 5}, []float64{1, 4, 5}),
+			wantExcluded: 2,
+		},
+		{
+			name:         "times of one population",
+			args:         getDataSet(epoch, []float64{1, 2, 3}, []float64{3, 4, 5}),
+			windows:      []TimeWindow{{FromSecs: epoch + 1, ToSecs: epoch + 1}, {FromSecs: epoch + 5, ToSecs: epoch + 9}},
+			want:         getDataSet(epoch, []float64{2, 3}, []float64{3, 4}),
+			wantExcluded: 2,
+		},
+		{
+			name:         "everything",
+			args:         getDataSet(epoch, []float64{1, 2}, []float64{1, 2}),
+			windows:      []TimeWindow{{FromSecs: epoch, ToSecs: epoch + 10}},
+			want:         getDataSet(epoch, []float64{}, []float64{}),
+			wantExcluded: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, excluded := excludeTimes(tt.args, tt.windows)
+			if !reflect.DeepEqual(got, tt.want) || excluded != tt.wantExcluded {
+				t.Errorf("excludeTimes() = %v, %d, want %v, %d", got, excluded, tt.want, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func Test_filterHours(t *testing.T) {
+	// 2023-04-22 00Z, the records are 6 hours apart
+	epoch := int64(1682121600)
+	matched := DataSet{}
+	for i := int64(0); i < 8; i++ {
+		matched.ctlPop = append(matched.ctlPop, PreCalcRecord{Avtime: epoch + i*6*3600, Stat: float64(i)})
+		matched.expPop = append(matched.expPop, PreCalcRecord{Avtime: epoch + i*6*3600, Stat: float64(10 + i)})
+	}
+	tests := []struct {
+		name        string
+		filter      HourFilter
+		fcstLenSecs int64
+		wantStats   []float64
+	}{
+		{name: "no filter", wantStats: []float64{0, 1, 2, 3, 4, 5, 6, 7}},
+		{name: "00Z and 12Z", filter: HourFilter{ValidHours: []int{0, 12}}, wantStats: []float64{0, 2, 4, 6}},
+		{name: "day", filter: HourFilter{ValidHours: []int{12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}}, wantStats: []float64{2, 3, 6, 7}},
+		{name: "init 00Z for 6h forecasts", filter: HourFilter{InitHours: []int{0}}, fcstLenSecs: 6 * 3600, wantStats: []float64{1, 5}},
+		{name: "valid and init", filter: HourFilter{ValidHours: []int{18}, InitHours: []int{6}}, fcstLenSecs: 12 * 3600, wantStats: []float64{3, 7}},
+		{name: "none", filter: HourFilter{ValidHours: []int{3}}, wantStats: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterHours(matched, tt.filter, tt.fcstLenSecs)
+			var stats []float64
+			for i := range got.ctlPop {
+				if got.expPop[i].Avtime != got.ctlPop[i].Avtime {
+					t.Fatalf("filterHours() record %d isn't matched", i)
+				}
+				stats = append(stats, got.ctlPop[i].Stat)
+			}
+			if !reflect.DeepEqual(stats, tt.wantStats) {
+				t.Errorf("filterHours() = %v, want %v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func Test_hourIn(t *testing.T) {
+	// before the epoch the hours still count from 00Z
+	if !hourIn([]int{23}, -3600) || hourIn([]int{0}, -1) || !hourIn(nil, 12345) {
+		t.Error("hourIn() is wrong for times before the epoch or for no hours")
+	}
+}
+
 // this test has inputs captured from a real world example
 func TestGetMatchedDataSetRealWorld(t *testing.T) {
 	defer goleak.VerifyNone(t)
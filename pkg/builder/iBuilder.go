@@ -41,6 +41,7 @@ A ScorecardCellBuilder is an interface that provides several functions:
 	significance values for an array of input data elements.
 */
 import (
+	"context"
 	"sync"
 )
 
@@ -60,6 +61,51 @@ type ValueStruct struct {
 	StatisticType    string
 	Pvalue           float64
 	Value            int
+	Excluded         int `json:",omitempty"` // the valid times that the exclusion windows removed before matching
+}
+
+// TimeWindow is a period of valid times, in epoch seconds, that is excluded from the statistics
+// e.g. an obs outage or a model crash. Both ends are in the window.
+type TimeWindow struct {
+	FromSecs int64
+	ToSecs   int64
+}
+
+// Contains reports whether the valid time avtime is in the window
+func (w TimeWindow) Contains(avtime int64) bool {
+	return avtime >= w.FromSecs && avtime <= w.ToSecs
+}
+
+// HourFilter selects the matched records that the statistics of a cell use by the UTC hour of their valid
+// time and of their init time, e.g. the 00Z and 12Z valid times or the daytime hours. An empty list of hours
+// selects every hour.
+type HourFilter struct {
+	ValidHours []int `json:"valid_hours,omitempty"` // 0 to 23
+	InitHours  []int `json:"init_hours,omitempty"`  // 0 to 23, the init time is the valid time minus the forecast length
+}
+
+// IsZero reports whether the filter selects every record
+func (f HourFilter) IsZero() bool {
+	return len(f.ValidHours) == 0 && len(f.InitHours) == 0
+}
+
+// selects reports whether the filter selects the valid time avtime of a cell whose forecast length is fcstLenSecs
+func (f HourFilter) selects(avtime, fcstLenSecs int64) bool {
+	return hourIn(f.ValidHours, avtime) && (len(f.InitHours) == 0 || hourIn(f.InitHours, avtime-fcstLenSecs))
+}
+
+// hourIn reports whether the UTC hour of the epoch is one of the hours, or there are no hours
+func hourIn(hours []int, epoch int64) bool {
+	if len(hours) == 0 {
+		return true
+	}
+	hour := int(((epoch%86400)+86400)%86400) / 3600
+	for _, h := range hours {
+		if h == hour {
+			return true
+		}
+	}
+	return false
 }
 
 type DerivedDataElement struct {
@@ -80,6 +126,10 @@ type (
 		pvalue           float64
 		keychain         []string
 		value            int
+		exclusions       []TimeWindow // the valid times that are dropped before matching
+		excluded         int          // the number of valid times that were dropped
+		hourFilter       HourFilter   // selects the matched records by their valid and init hours
+		fcstLenSecs      int64        // the forecast length of the cell, for the init hours of the hourFilter
 	}
 )
 
@@ -232,7 +282,7 @@ type ScorecardCellBuilder interface {
 	GetStatisticType() StatisticType
 	setValue(value int32)
 	SetStatisticType(statisticType string)
-	Build(qrPtr interface{}, statisticType string, minorThreshold float64, majorThreshold float64)
+	Build(ctx context.Context, qrPtr interface{}, statisticType string, minorThreshold float64, majorThreshold float64)
 }
 
 func GetBuilder(builderType string) *ScorecardCell {
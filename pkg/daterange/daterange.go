@@ -0,0 +1,254 @@
+// Package daterange parses the dateRange of a scorecard document. A date range is one of
+//
+//   - two times separated by " - ", like the scorecard app writes them: "02/19/2023 20:00 - 03/21/2023 20:00".
+//     Each time is "01/02/2006 15:04", an ISO 8601 date or date and time like "2023-02-19" or
+//     "2023-02-19T20:00:00Z", or either of them followed by a zone: "02/19/2023 20:00 America/Denver",
+//     "2023-02-19T20:00 -07:00". A time without a zone is UTC.
+//   - an ISO 8601 interval: "2023-02-19T20:00Z/2023-03-21T20:00Z", "2023-02-19/P30D" or "P1M/2023-03-21T00:00Z".
+//   - a range relative to now: "last 30 days", "last 12 hours" or "last 2 weeks ending at the latest 00Z".
+//     It ends at the start of the current hour, or at the latest time before now that has the given UTC hour.
+package daterange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // the zones don't depend on the zoneinfo of the host
+)
+
+// Range is a date range, From is not after To
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// legacyLayout is the layout of the times of the scorecard app
+const legacyLayout = "01/02/2006 15:04"
+
+// zonedLayouts are the ISO 8601 layouts that have a zone, "Z07:00" matches "Z" and offsets like "-07:00"
+var zonedLayouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+}
+
+// localLayouts are the layouts without a zone, the times are UTC unless a zone follows them
+var localLayouts = []string{
+	legacyLayout,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var (
+	rangeSeparator = regexp.MustCompile(`\s+-\s+`)
+	offsetZone     = regexp.MustCompile(`^([+-])(\d\d):?(\d\d)$`)
+	relativeRange  = regexp.MustCompile(`^last (\d{1,6}) (hours?|days?|weeks?)(?: ending at the latest (\d{1,2})z)?$`)
+	isoDuration    = regexp.MustCompile(`^P(?:(\d{1,6})Y)?(?:(\d{1,6})M)?(?:(\d{1,6})W)?(?:(\d{1,6})D)?(?:T(?:(\d{1,6})H)?(?:(\d{1,6})M)?(?:(\d{1,6})S)?)?$`)
+)
+
+// Parse parses a date range, relative ranges are relative to now
+func Parse(s string, now time.Time) (Range, error) {
+	s = strings.TrimSpace(s)
+	var r Range
+	var err error
+	switch {
+	case s == "":
+		return Range{}, fmt.Errorf("daterange Parse error the date range is empty")
+	case strings.HasPrefix(strings.ToLower(s), "last "):
+		r, err = parseRelative(s, now)
+	case rangeSeparator.MatchString(s):
+		r, err = parseTimes(s)
+	case strings.Count(s, "/") == 1:
+		r, err = parseInterval(s)
+	default:
+		return Range{}, fmt.Errorf("daterange Parse error %q is not a date range like \"02/19/2023 20:00 - 03/21/2023 20:00\", "+
+			"\"2023-02-19T20:00Z/2023-03-21T20:00Z\" or \"last 30 days\"", s)
+	}
+	if err != nil {
+		return Range{}, fmt.Errorf("daterange Parse error in %q: %w", s, err)
+	}
+	if r.From.After(r.To) {
+		return Range{}, fmt.Errorf("daterange Parse error in %q: the start %v is after the end %v", s, r.From, r.To)
+	}
+	return r, nil
+}
+
+// parseTimes parses two times separated by " - "
+func parseTimes(s string) (Range, error) {
+	parts := rangeSeparator.Split(s, -1)
+	if len(parts) != 2 {
+		return Range{}, fmt.Errorf("there are %d times separated by \" - \", want 2", len(parts))
+	}
+	from, err := parseTime(parts[0])
+	if err != nil {
+		return Range{}, fmt.Errorf("the start: %w", err)
+	}
+	to, err := parseTime(parts[1])
+	if err != nil {
+		return Range{}, fmt.Errorf("the end: %w", err)
+	}
+	return Range{From: from, To: to}, nil
+}
+
+// parseInterval parses an ISO 8601 interval of two times, a time and a duration or a duration and a time
+func parseInterval(s string) (Range, error) {
+	start, end, _ := strings.Cut(s, "/")
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	startIsDuration, endIsDuration := strings.HasPrefix(start, "P"), strings.HasPrefix(end, "P")
+	switch {
+	case startIsDuration && endIsDuration:
+		return Range{}, fmt.Errorf("an interval has at most one duration")
+	case startIsDuration:
+		d, err := parseDuration(start)
+		if err != nil {
+			return Range{}, err
+		}
+		to, err := parseTime(end)
+		if err != nil {
+			return Range{}, fmt.Errorf("the end: %w", err)
+		}
+		return Range{From: d.add(to, -1), To: to}, nil
+	case endIsDuration:
+		from, err := parseTime(start)
+		if err != nil {
+			return Range{}, fmt.Errorf("the start: %w", err)
+		}
+		d, err := parseDuration(end)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{From: from, To: d.add(from, 1)}, nil
+	default:
+		return parseTimes(start + " - " + end)
+	}
+}
+
+// parseRelative parses a range like "last 30 days ending at the latest 00Z"
+func parseRelative(s string, now time.Time) (Range, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	match := relativeRange.FindStringSubmatch(normalized)
+	if match == nil {
+		return Range{}, fmt.Errorf("a relative range is like \"last 30 days\" or \"last 30 days ending at the latest 00Z\"")
+	}
+	n, _ := strconv.Atoi(match[1]) // at most 6 digits
+	if n < 1 {
+		return Range{}, fmt.Errorf("the range must be at least one %s", strings.TrimSuffix(match[2], "s"))
+	}
+	now = now.UTC()
+	to := now.Truncate(time.Hour)
+	if match[3] != "" {
+		hour, _ := strconv.Atoi(match[3])
+		if hour > 23 {
+			return Range{}, fmt.Errorf("the hour %dZ is not between 00Z and 23Z", hour)
+		}
+		to = time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if to.After(now) {
+			to = to.AddDate(0, 0, -1)
+		}
+	}
+	var from time.Time
+	switch strings.TrimSuffix(match[2], "s") {
+	case "hour":
+		from = to.Add(-time.Duration(n) * time.Hour)
+	case "day":
+		from = to.AddDate(0, 0, -n)
+	case "week":
+		from = to.AddDate(0, 0, -7*n)
+	}
+	return Range{From: from, To: to}, nil
+}
+
+// parseTime parses a time, it is UTC unless it has a zone
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("the time is empty")
+	}
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	// a time followed by a zone
+	if i := strings.LastIndex(s, " "); i > 0 {
+		local, zoneName := strings.TrimSpace(s[:i]), s[i+1:]
+		loc, zoneErr := zone(zoneName)
+		for _, layout := range localLayouts {
+			if _, err := time.Parse(layout, local); err != nil {
+				continue
+			}
+			if zoneErr != nil {
+				// the time is right but the zone isn't
+				return time.Time{}, zoneErr
+			}
+			return time.ParseInLocation(layout, local, loc)
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time like \"02/19/2023 20:00\", \"2023-02-19T20:00:00Z\" or \"2023-02-19T20:00 America/Denver\"", s)
+}
+
+// zone returns the location of a zone name: Z, UTC, an offset like -07:00 or an IANA zone like America/Denver
+func zone(name string) (*time.Location, error) {
+	switch name {
+	case "Z", "UTC", "GMT":
+		return time.UTC, nil
+	case "", "Local":
+		// LoadLocation would return UTC or the zone of the host
+		return nil, fmt.Errorf("%q is not a zone", name)
+	}
+	if match := offsetZone.FindStringSubmatch(name); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		minutes, _ := strconv.Atoi(match[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("the offset %q is out of range", name)
+		}
+		offset := hours*3600 + minutes*60
+		if match[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(name, offset), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a zone like UTC, -07:00 or America/Denver", name)
+	}
+	return loc, nil
+}
+
+// duration is an ISO 8601 duration, the dates are calendar dates and the clock is elapsed time
+type duration struct {
+	years, months, days int
+	clock               time.Duration
+}
+
+// parseDuration parses an ISO 8601 duration like P30D, P1M or PT12H
+func parseDuration(s string) (duration, error) {
+	match := isoDuration.FindStringSubmatch(s)
+	if match == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return duration{}, fmt.Errorf("%q is not an ISO 8601 duration like P30D, P1M or PT12H", s)
+	}
+	n := make([]int, len(match))
+	for i, value := range match[1:] {
+		n[i+1], _ = strconv.Atoi(value) // at most 6 digits, or empty
+	}
+	return duration{
+		years:  n[1],
+		months: n[2],
+		days:   7*n[3] + n[4],
+		clock:  time.Duration(n[5])*time.Hour + time.Duration(n[6])*time.Minute + time.Duration(n[7])*time.Second,
+	}, nil
+}
+
+// add adds the duration to t, or subtracts it for a negative sign
+func (d duration) add(t time.Time, sign int) time.Time {
+	return t.AddDate(sign*d.years, sign*d.months, sign*d.days).Add(time.Duration(sign) * d.clock)
+}
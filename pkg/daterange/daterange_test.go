@@ -0,0 +1,124 @@
+package daterange
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is a fixed time for the relative ranges, 2023-04-23 13:45 UTC
+var now = time.Date(2023, 4, 23, 13, 45, 30, 0, time.UTC)
+
+func utc(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{name: "legacy", s: "02/19/2023 20:00 - 03/21/2023 20:00", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 3, 21, 20, 0)},
+		{name: "legacy spaces", s: "  02/19/2023 20:00   -  03/21/2023 20:00 ", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 3, 21, 20, 0)},
+		{name: "same times", s: "02/19/2023 20:00 - 02/19/2023 20:00", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 2, 19, 20, 0)},
+		{name: "legacy with zone", s: "02/19/2023 20:00 America/Denver - 03/21/2023 20:00 America/Denver",
+			wantFrom: utc(2023, 2, 20, 3, 0), wantTo: utc(2023, 3, 22, 2, 0)},
+		{name: "legacy with offset", s: "02/19/2023 20:00 -07:00 - 03/21/2023 20:00 +0130",
+			wantFrom: utc(2023, 2, 20, 3, 0), wantTo: utc(2023, 3, 21, 18, 30)},
+		{name: "ISO times", s: "2023-02-19T20:00:00Z - 2023-03-21T20:00:00-06:00", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 3, 22, 2, 0)},
+		{name: "ISO dates", s: "2023-02-19 - 2023-03-21", wantFrom: utc(2023, 2, 19, 0, 0), wantTo: utc(2023, 3, 21, 0, 0)},
+		{name: "ISO local times with zone", s: "2023-02-19 20:00 UTC - 2023-03-21T20:00 Europe/Berlin",
+			wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 3, 21, 19, 0)},
+		{name: "interval", s: "2023-02-19T20:00Z/2023-03-21T20:00Z", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 3, 21, 20, 0)},
+		{name: "interval with start and duration", s: "2023-02-19/P30D", wantFrom: utc(2023, 2, 19, 0, 0), wantTo: utc(2023, 3, 21, 0, 0)},
+		{name: "interval with duration and end", s: "P1M/2023-03-21T00:00Z", wantFrom: utc(2023, 2, 21, 0, 0), wantTo: utc(2023, 3, 21, 0, 0)},
+		{name: "interval with clock duration", s: "2023-02-19T20:00Z/P1DT12H30M", wantFrom: utc(2023, 2, 19, 20, 0), wantTo: utc(2023, 2, 21, 8, 30)},
+		{name: "interval with weeks", s: "P2W/2023-03-21", wantFrom: utc(2023, 3, 7, 0, 0), wantTo: utc(2023, 3, 21, 0, 0)},
+		{name: "last days", s: "last 30 days", wantFrom: utc(2023, 3, 24, 13, 0), wantTo: utc(2023, 4, 23, 13, 0)},
+		{name: "last hour", s: "Last 1 hour", wantFrom: utc(2023, 4, 23, 12, 0), wantTo: utc(2023, 4, 23, 13, 0)},
+		{name: "last weeks", s: "last 2 weeks", wantFrom: utc(2023, 4, 9, 13, 0), wantTo: utc(2023, 4, 23, 13, 0)},
+		{name: "ending at an earlier hour", s: "last 30 days ending at the latest 00Z", wantFrom: utc(2023, 3, 24, 0, 0), wantTo: utc(2023, 4, 23, 0, 0)},
+		{name: "ending at a later hour", s: "last 30 days ending at the latest 18Z", wantFrom: utc(2023, 3, 23, 18, 0), wantTo: utc(2023, 4, 22, 18, 0)},
+		{name: "ending at the current hour", s: "LAST 12 HOURS  ending at the latest 13z", wantFrom: utc(2023, 4, 23, 1, 0), wantTo: utc(2023, 4, 23, 13, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !got.From.Equal(tt.wantFrom) || !got.To.Equal(tt.wantTo) {
+				t.Errorf("Parse() = %v - %v, want %v - %v", got.From.UTC(), got.To.UTC(), tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr string
+	}{
+		{name: "empty", s: " ", wantErr: "the date range is empty"},
+		{name: "one time", s: "02/19/2023 20:00", wantErr: "is not a date range"},
+		{name: "no spaces around the separator", s: "02/19/2023 20:00-03/21/2023 20:00", wantErr: "is not a date range"},
+		{name: "three times", s: "02/19/2023 20:00 - 03/21/2023 20:00 - 04/21/2023 20:00", wantErr: "there are 3 times"},
+		{name: "bad start", s: "yesterday - 03/21/2023 20:00", wantErr: `the start: "yesterday" is not a time`},
+		{name: "bad end", s: "02/19/2023 20:00 - 13/21/2023 20:00", wantErr: `the end: "13/21/2023 20:00" is not a time`},
+		{name: "bad zone", s: "02/19/2023 20:00 Mars/Olympus - 03/21/2023 20:00", wantErr: `"Mars/Olympus" is not a zone`},
+		{name: "host zone", s: "02/19/2023 20:00 Local - 03/21/2023 20:00", wantErr: `"Local" is not a zone`},
+		{name: "bad offset", s: "02/19/2023 20:00 +15:00 - 03/21/2023 20:00", wantErr: `the offset "+15:00" is out of range`},
+		{name: "reversed", s: "03/21/2023 20:00 - 02/19/2023 20:00", wantErr: "is after the end"},
+		{name: "two durations", s: "P1D/P2D", wantErr: "at most one duration"},
+		{name: "bad duration", s: "2023-02-19/P30", wantErr: `"P30" is not an ISO 8601 duration`},
+		{name: "empty duration", s: "2023-02-19/PT", wantErr: `"PT" is not an ISO 8601 duration`},
+		{name: "bad interval end", s: "2023-02-19/tomorrow", wantErr: `the end: "tomorrow" is not a time`},
+		{name: "bad relative", s: "last month", wantErr: "a relative range is like"},
+		{name: "zero relative", s: "last 0 days", wantErr: "at least one day"},
+		{name: "bad hour", s: "last 3 days ending at the latest 24Z", wantErr: "the hour 24Z is not between"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s, now)
+			if err == nil {
+				t.Fatalf("Parse() = %v, want an error", got)
+			}
+			if !strings.HasPrefix(err.Error(), "daterange Parse error") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"02/19/2023 20:00 - 03/21/2023 20:00",
+		"02/19/2023 20:00 America/Denver - 03/21/2023 20:00 -07:00",
+		"2023-02-19T20:00:00Z - 2023-03-21 20:00 UTC",
+		"2023-02-19T20:00Z/2023-03-21T20:00Z",
+		"2023-02-19/P1Y2M3W4DT5H6M7S",
+		"P1M/2023-03-21T00:00Z",
+		"last 30 days ending at the latest 00Z",
+		"last 999999 weeks",
+		"02/19/2023 20:00 - ",
+		" - ",
+		"/",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		r, err := Parse(s, now)
+		if err != nil {
+			if !strings.HasPrefix(err.Error(), "daterange Parse error") {
+				t.Errorf("Parse(%q) error = %v, want a daterange Parse error", s, err)
+			}
+			return
+		}
+		if r.From.After(r.To) {
+			t.Errorf("Parse(%q) = %v - %v, the start is after the end", s, r.From, r.To)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package director
+
+/*
+A ConnectionPool is the MySQL connection pool that the managers of a process share between all of their
+directors. database/sql already maintains a pool of connections per *sql.DB, so the ConnectionPool only has
+to make sure that there is one capped *sql.DB and that its statistics are exported. Directors borrow the
+pool, they never close it - the manager package keeps it open for the life of the process.
+*/
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxOpenConns is the connection cap of a pool if the configuration doesn't specify one
+const DefaultMaxOpenConns = 20
+
+type ConnectionPool struct {
+	db        *sql.DB
+	closeOnce sync.Once
+}
+
+// getMySqlConnection establishes a connection to the given SQL database
+// connection strings should be like: user:password@tcp(localhost:5555)
+func getMySqlConnection(mysqlCredentials DbCredentials, maxOpenConns int) (*sql.DB, error) {
+	// get the connection
+	driver := "mysql"
+	dataSource := fmt.Sprintf("%s:%s@tcp(%s)/", mysqlCredentials.User, mysqlCredentials.Password, mysqlCredentials.Host)
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("mysql_director getMySqlConnection sql open error %w", err)
+	}
+	db.SetConnMaxLifetime(time.Minute * 3)
+	db.SetMaxOpenConns(maxOpenConns)
+	// keep the idle connections so that the directors can reuse each other's connections
+	db.SetMaxIdleConns(maxOpenConns)
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelfunc()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("mysql_director Build sql open/ping error: %w", err)
+	}
+	return db, nil
+}
+
+// NewConnectionPool opens a MySQL connection pool with at most maxOpenConns connections.
+// Callers should make sure to call Close() when they're done with the pool.
+func NewConnectionPool(mysqlCredentials DbCredentials, maxOpenConns int) (*ConnectionPool, error) {
+	if maxOpenConns < 1 {
+		maxOpenConns = DefaultMaxOpenConns
+	}
+	db, err := getMySqlConnection(mysqlCredentials, maxOpenConns)
+	if err != nil {
+		return nil, fmt.Errorf("director NewConnectionPool error: %w", err)
+	}
+	return newConnectionPool(db), nil
+}
+
+// newConnectionPool wraps an open database and starts exporting its statistics
+func newConnectionPool(db *sql.DB) *ConnectionPool {
+	pool := &ConnectionPool{db: db}
+	connectionPools.add(pool)
+	return pool
+}
+
+// Stats returns the database/sql statistics of the pool
+func (pool *ConnectionPool) Stats() sql.DBStats {
+	return pool.db.Stats()
+}
+
+// Close closes the pool. It is safe to call more than once.
+func (pool *ConnectionPool) Close() error {
+	var err error
+	pool.closeOnce.Do(func() {
+		connectionPools.remove(pool)
+		err = pool.db.Close()
+	})
+	return err
+}
+
+// poolCollector exports the statistics of all the open connection pools. The wait counters of
+// closed pools are kept so that the exported counters never go backwards.
+type poolCollector struct {
+	lock               sync.Mutex
+	pools              map[*ConnectionPool]struct{}
+	closedWaitCount    int64
+	closedWaitDuration time.Duration
+
+	inUse        *prometheus.Desc
+	open         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newPoolCollector() *poolCollector {
+	return &poolCollector{
+		pools: map[*ConnectionPool]struct{}{},
+		inUse: prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "mysql_connections_in_use"),
+			"Number of MySQL connections that are currently running a query.", nil, nil),
+		open: prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "mysql_connections_open"),
+			"Number of established MySQL connections, both in use and idle.", nil, nil),
+		waitCount: prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "mysql_connection_waits_total"),
+			"Number of times a query had to wait for a free MySQL connection.", nil, nil),
+		waitDuration: prometheus.NewDesc(prometheus.BuildFQName("", subsystem, "mysql_connection_wait_seconds_total"),
+			"Total time queries have waited for a free MySQL connection.", nil, nil),
+	}
+}
+
+func (c *poolCollector) add(pool *ConnectionPool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pools[pool] = struct{}{}
+}
+
+func (c *poolCollector) remove(pool *ConnectionPool) {
+	stats := pool.Stats()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.pools[pool]; !ok {
+		return
+	}
+	delete(c.pools, pool)
+	c.closedWaitCount += stats.WaitCount
+	c.closedWaitDuration += stats.WaitDuration
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.inUse
+	ch <- c.open
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	inUse, open := 0, 0
+	waitCount, waitDuration := c.closedWaitCount, c.closedWaitDuration
+	for pool := range c.pools {
+		stats := pool.Stats()
+		inUse += stats.InUse
+		open += stats.OpenConnections
+		waitCount += stats.WaitCount
+		waitDuration += stats.WaitDuration
+	}
+	c.lock.Unlock()
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(inUse))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(open))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(waitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, waitDuration.Seconds())
+}
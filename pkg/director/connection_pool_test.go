@@ -0,0 +1,63 @@
+package director
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_poolCollector(t *testing.T) {
+	db, _ := newFakeDB(t, scalarTestRows)
+	db.SetMaxOpenConns(1)
+	collector := newPoolCollector()
+	pool := &ConnectionPool{db: db}
+	collector.add(pool)
+	gauges := []string{"director_mysql_connections_in_use", "director_mysql_connections_open", "director_mysql_connection_waits_total"}
+	expect := func(inUse, open, waits string) {
+		t.Helper()
+		want := "# HELP director_mysql_connection_waits_total Number of times a query had to wait for a free MySQL connection.\n" +
+			"# TYPE director_mysql_connection_waits_total counter\n" +
+			"director_mysql_connection_waits_total " + waits + "\n" +
+			"# HELP director_mysql_connections_in_use Number of MySQL connections that are currently running a query.\n" +
+			"# TYPE director_mysql_connections_in_use gauge\n" +
+			"director_mysql_connections_in_use " + inUse + "\n" +
+			"# HELP director_mysql_connections_open Number of established MySQL connections, both in use and idle.\n" +
+			"# TYPE director_mysql_connections_open gauge\n" +
+			"director_mysql_connections_open " + open + "\n"
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(want), gauges...); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// hold the only connection so that a query has to wait for it
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect("1", "1", "0")
+	done := make(chan error)
+	go func() {
+		rows, err := db.QueryContext(ctx, "select 1")
+		if err == nil {
+			rows.Close()
+		}
+		done <- err
+	}()
+	for db.Stats().WaitCount == 0 {
+		runtime.Gosched()
+	}
+	conn.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	expect("0", "1", "1")
+
+	// the wait count of a closed pool is kept
+	collector.remove(pool)
+	db.Close()
+	expect("0", "0", "1")
+}
@@ -0,0 +1,80 @@
+package director
+
+/*
+A dry run walks the cells of a region the same way Run does and renders the control and experimental
+query templates of every cell, but it does not query the database or modify the region. It reports
+the statements that would be run and the problems that would make a cell fail, so that a scorecard
+can be checked before it is processed.
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// CellPlan is what a dry run found out about one cell
+type CellPlan struct {
+	Path              string   `json:"path"`
+	StatisticType     string   `json:"statistic_type"`
+	DataType          string   `json:"data_type,omitempty"` // CTC, Scalar or PreCalc
+	ControlQuery      string   `json:"control_query,omitempty"`
+	ExperimentalQuery string   `json:"experimental_query,omitempty"`
+	Problems          []string `json:"problems,omitempty"`
+}
+
+// GetDryRunDirector returns a director that has no database connections, it can only DryRun
+func GetDryRunDirector(directorType string, dateRange DateRange) (*Director, error) {
+	if directorType != "MysqlDirector" {
+		return nil, fmt.Errorf("Director GetDryRunDirector unsupported directorType: %q", directorType)
+	}
+	return &Director{
+		queryBlock:  ScorecardBlock{},
+		resultBlock: ScorecardBlock{},
+		dateRange:   dateRange,
+		workers:     DefaultWorkers,
+	}, nil
+}
+
+// DryRun renders and checks the query templates of every cell of a region. It returns an error if the
+// cells can't be enumerated (e.g. the region and its queryMap don't match), problems with individual
+// cells are reported in their CellPlan.
+func (director *Director) DryRun(queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion) ([]CellPlan, error) {
+	cells, err := director.enumerateCells(queryRegionName, region, queryRegion)
+	if err != nil {
+		return nil, fmt.Errorf("mysql_director error in DryRun %w", err)
+	}
+	plans := make([]CellPlan, 0, len(cells))
+	for _, c := range cells {
+		plans = append(plans, director.planCell(c))
+	}
+	return plans, nil
+}
+
+// planCell renders the queries of a cell and detects its data type, the same way processCell does
+func (director *Director) planCell(c *cell) CellPlan {
+	plan := CellPlan{
+		Path:          strings.Join(c.keychain, " -> "),
+		StatisticType: fmt.Sprint(c.statisticType),
+	}
+	if c.statisticType == builder.Unknown {
+		plan.Problems = append(plan.Problems, "the statistic of the cell is unknown")
+	}
+	ctlQuery, expQuery, err := director.renderLeafQueries(c.keychain, c.queryLeaf, director.dateRange)
+	if err != nil {
+		plan.Problems = append(plan.Problems, err.Error())
+		return plan
+	}
+	plan.ControlQuery = ctlQuery
+	plan.ExperimentalQuery = expQuery
+	plan.DataType = queryDataType(ctlQuery)
+	switch expDataType := queryDataType(expQuery); {
+	case plan.DataType == "":
+		plan.Problems = append(plan.Problems, "unknown data type, the control query selects none of hit, square_diff_sum or stat")
+	case expDataType != plan.DataType:
+		plan.Problems = append(plan.Problems, fmt.Sprintf("the control query returns %s data but the experimental query returns %q data", plan.DataType, expDataType))
+	}
+	return plan
+}
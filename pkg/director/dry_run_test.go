@@ -0,0 +1,58 @@
+package director
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func TestDirector_DryRun(t *testing.T) {
+	director, err := GetDryRunDirector("MysqlDirector", DateRange{FromSecs: 100, ToSecs: 86400})
+	if err != nil {
+		t.Fatalf("GetDryRunDirector() error %v", err)
+	}
+	region, queryRegion := scalarTestRegion("0", "3", "6", "9")
+	leaves := queryRegion["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	leaves["3"] = scorecard.QueryLeaf{ControlQueryTemplate: leaves["3"].ControlQueryTemplate, ExperimentalQueryTemplate: "SELECT {{threshold}} AS square_diff_sum"}
+	leaves["6"] = scorecard.QueryLeaf{ControlQueryTemplate: "SELECT 1 AS avtime", ExperimentalQueryTemplate: leaves["6"].ExperimentalQueryTemplate}
+	leaves["9"] = scorecard.QueryLeaf{ControlQueryTemplate: leaves["9"].ControlQueryTemplate, ExperimentalQueryTemplate: "SELECT stat FROM t"}
+
+	// the director has no database, a query would panic
+	plans, err := director.DryRun("All HRRR domain", region, queryRegion)
+	if err != nil {
+		t.Fatalf("DryRun() error %v", err)
+	}
+	tests := []struct {
+		fcst         string
+		wantDataType string
+		wantProblem  string
+	}{
+		{fcst: "0", wantDataType: "Scalar"},
+		{fcst: "3", wantProblem: "unfilled placeholders [threshold]"},
+		{fcst: "6", wantProblem: "unknown data type"},
+		{fcst: "9", wantDataType: "Scalar", wantProblem: `returns Scalar data but the experimental query returns "PreCalc" data`},
+	}
+	if len(plans) != len(tests) {
+		t.Fatalf("DryRun() returned %d cells, want %d", len(plans), len(tests))
+	}
+	for i, tt := range tests {
+		plan := plans[i]
+		wantPath := "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> " + tt.fcst
+		if plan.Path != wantPath || plan.StatisticType != "RMSE" || plan.DataType != tt.wantDataType {
+			t.Errorf("DryRun() cell %s = %q %q %q", tt.fcst, plan.Path, plan.StatisticType, plan.DataType)
+		}
+		problems := strings.Join(plan.Problems, "; ")
+		if (tt.wantProblem == "") != (problems == "") || !strings.Contains(problems, tt.wantProblem) {
+			t.Errorf("DryRun() cell %s problems %q, want %q", tt.fcst, problems, tt.wantProblem)
+		}
+	}
+	if !strings.Contains(plans[0].ControlQuery, ">= 100 AND") || !strings.Contains(plans[0].ExperimentalQuery, "RRFS_A") {
+		t.Errorf("DryRun() rendered %q and %q", plans[0].ControlQuery, plans[0].ExperimentalQuery)
+	}
+	untouched, _ := scalarTestRegion("0", "3", "6", "9")
+	if !reflect.DeepEqual(region, untouched) {
+		t.Errorf("DryRun() modified the region")
+	}
+}
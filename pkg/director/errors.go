@@ -0,0 +1,204 @@
+package director
+
+/*
+Query errors are classified so that a scorecard run can tell cells that have no data apart
+from cells whose query is broken. The class is derived from the MySQL server error number
+when there is one (see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html),
+otherwise from the context and driver errors.
+*/
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type ErrorClass string
+
+const (
+	ErrorClassNoData           ErrorClass = "no_data"           // the query ran but returned no rows
+	ErrorClassMissingTable     ErrorClass = "missing_table"     // the table for the model or region does not exist
+	ErrorClassNullData         ErrorClass = "null_data"         // the query returned NULL for a statistic column
+	ErrorClassSyntax           ErrorClass = "syntax"            // the rendered statement is not valid SQL
+	ErrorClassTimeout          ErrorClass = "timeout"           // the query was cancelled by a timeout
+	ErrorClassConnectionLost   ErrorClass = "connection_lost"   // the connection to the server went away
+	ErrorClassDeadlock         ErrorClass = "deadlock"          // the query was chosen as a deadlock victim or waited too long for a lock
+	ErrorClassPermissionDenied ErrorClass = "permission_denied" // the user may not read the table
+	ErrorClassCancelled        ErrorClass = "cancelled"         // the run, or the region, was cancelled before the query finished
+	ErrorClassInvalidCell      ErrorClass = "invalid_cell"      // the keys of the cell can't be processed, e.g. a forecast length that isn't in hours
+	ErrorClassUnknown          ErrorClass = "unknown"
+)
+
+// MySQL server error numbers
+const (
+	mysqlErrAccessDenied         = 1045
+	mysqlErrDBAccessDenied       = 1044
+	mysqlErrTableAccessDenied    = 1142
+	mysqlErrColumnAccessDenied   = 1143
+	mysqlErrParse                = 1064
+	mysqlErrBadField             = 1054
+	mysqlErrNoSuchTable          = 1146
+	mysqlErrBadDB                = 1049
+	mysqlErrQueryInterrupted     = 1317
+	mysqlErrQueryTimeout         = 3024
+	mysqlErrServerGone           = 2006
+	mysqlErrServerLost           = 2013
+	mysqlErrLockWaitTimeout      = 1205
+	mysqlErrLockDeadlock         = 1213
+	mysqlErrServerShutdown       = 1053
+	mysqlErrConnectionKilled     = 1927
+	mysqlErrStatementTimeMax     = 1028
+	mysqlErrTooManyConnections   = 1040
+	mysqlErrUserTooManyConns     = 1203
+	mysqlErrHostNotPrivileged    = 1130
+	mysqlErrSpecificAccessDenied = 1227
+)
+
+// QueryError is the error of a director query, it carries the class of the failure
+type QueryError struct {
+	Class     ErrorClass
+	Statement string
+	Err       error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s query error: %v", e.Class, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// newQueryError classifies err and wraps it in a QueryError. A nil err stays nil.
+func newQueryError(stmnt string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return err
+	}
+	return &QueryError{Class: classifyError(err), Statement: stmnt, Err: err}
+}
+
+// ErrorClassOf returns the class of a query error or ErrorClassUnknown if it isn't a QueryError
+func ErrorClassOf(err error) ErrorClass {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Class
+	}
+	return ErrorClassUnknown
+}
+
+// classifyError derives the class of a query error from the MySQL error number, the context, or the driver
+func classifyError(err error) ErrorClass {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrNoSuchTable, mysqlErrBadDB:
+			return ErrorClassMissingTable
+		case mysqlErrParse, mysqlErrBadField:
+			return ErrorClassSyntax
+		case mysqlErrAccessDenied, mysqlErrDBAccessDenied, mysqlErrTableAccessDenied, mysqlErrColumnAccessDenied,
+			mysqlErrHostNotPrivileged, mysqlErrSpecificAccessDenied:
+			return ErrorClassPermissionDenied
+		case mysqlErrQueryTimeout, mysqlErrQueryInterrupted, mysqlErrStatementTimeMax:
+			return ErrorClassTimeout
+		case mysqlErrLockDeadlock, mysqlErrLockWaitTimeout:
+			return ErrorClassDeadlock
+		case mysqlErrServerGone, mysqlErrServerLost, mysqlErrServerShutdown, mysqlErrConnectionKilled,
+			mysqlErrTooManyConnections, mysqlErrUserTooManyConns:
+			return ErrorClassConnectionLost
+		}
+		return ErrorClassUnknown
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCancelled
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn):
+		return ErrorClassConnectionLost
+	// database/sql does not have a typed error for scanning a NULL into a number
+	case strings.Contains(err.Error(), "converting NULL"):
+		return ErrorClassNullData
+	}
+	return ErrorClassUnknown
+}
+
+// expected reports whether an error class is a normal condition of a scorecard (a model or region without data)
+// rather than a broken query - expected errors are counted but not logged
+func (class ErrorClass) expected() bool {
+	return class == ErrorClassNoData || class == ErrorClassMissingTable || class == ErrorClassNullData
+}
+
+// transient reports whether a failed query is worth running again
+func (class ErrorClass) transient() bool {
+	return class == ErrorClassConnectionLost || class == ErrorClassDeadlock
+}
+
+// isTransientQueryError is the retry test for director queries
+func isTransientQueryError(err error) bool {
+	return ErrorClassOf(err).transient()
+}
+
+// RunSummary counts the cells that the directors of a run processed and the cells that failed, by error class.
+// It is safe for concurrent use by several directors.
+type RunSummary struct {
+	cells  atomic.Int64
+	lock   sync.Mutex
+	errors map[ErrorClass]int64
+}
+
+// addCells counts processed cells
+func (rs *RunSummary) addCells(n int) {
+	rs.cells.Add(int64(n))
+}
+
+// addError counts a cell that failed with the given class
+func (rs *RunSummary) addError(class ErrorClass) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if rs.errors == nil {
+		rs.errors = map[ErrorClass]int64{}
+	}
+	rs.errors[class]++
+	cellErrors.WithLabelValues(string(class)).Inc()
+}
+
+// Cells returns the number of cells processed so far
+func (rs *RunSummary) Cells() int64 {
+	return rs.cells.Load()
+}
+
+// ErrorCounts returns the number of failed cells per error class
+func (rs *RunSummary) ErrorCounts() map[ErrorClass]int64 {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	counts := make(map[ErrorClass]int64, len(rs.errors))
+	for class, n := range rs.errors {
+		counts[class] = n
+	}
+	return counts
+}
+
+// String returns the error counts sorted by class e.g. "missing_table: 4, no_data: 12"
+func (rs *RunSummary) String() string {
+	counts := rs.ErrorCounts()
+	if len(counts) == 0 {
+		return "no cell errors"
+	}
+	parts := make([]string, 0, len(counts))
+	for class, n := range counts {
+		parts = append(parts, fmt.Sprintf("%s: %d", class, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,173 @@
+package director
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+	"github.com/go-sql-driver/mysql"
+)
+
+func Test_classifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{name: "missing table", err: &mysql.MySQLError{Number: 1146, Message: "Table 'ceiling_sums2.NAM_E_ALL' doesn't exist"}, want: ErrorClassMissingTable},
+		{name: "syntax", err: &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}, want: ErrorClassSyntax},
+		{name: "unknown column", err: &mysql.MySQLError{Number: 1054, Message: "Unknown column 'm0.trsh'"}, want: ErrorClassSyntax},
+		{name: "permission", err: &mysql.MySQLError{Number: 1142, Message: "SELECT command denied"}, want: ErrorClassPermissionDenied},
+		{name: "server timeout", err: &mysql.MySQLError{Number: 3024, Message: "maximum statement execution time exceeded"}, want: ErrorClassTimeout},
+		{name: "deadlock", err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, want: ErrorClassDeadlock},
+		{name: "lock wait", err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, want: ErrorClassDeadlock},
+		{name: "server gone", err: &mysql.MySQLError{Number: 2006, Message: "MySQL server has gone away"}, want: ErrorClassConnectionLost},
+		{name: "other server error", err: &mysql.MySQLError{Number: 1366, Message: "Incorrect integer value"}, want: ErrorClassUnknown},
+		{name: "query timeout", err: fmt.Errorf("mysql_director queryData Query failed: %w", context.DeadlineExceeded), want: ErrorClassTimeout},
+		{name: "cancelled", err: fmt.Errorf("mysql_director queryData Query failed: %w", context.Canceled), want: ErrorClassCancelled},
+		{name: "bad connection", err: driver.ErrBadConn, want: ErrorClassConnectionLost},
+		{name: "invalid connection", err: mysql.ErrInvalidConn, want: ErrorClassConnectionLost},
+		{name: "null", err: errors.New(`sql: Scan error on column index 1, name "hit": converting NULL to float64 is unsupported`), want: ErrorClassNullData},
+		{name: "wrapped", err: fmt.Errorf("outer: %w", &mysql.MySQLError{Number: 1146}), want: ErrorClassMissingTable},
+		{name: "other", err: errors.New("something else"), want: ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+			if got := ErrorClassOf(newQueryError("select 1", tt.err)); got != tt.want {
+				t.Errorf("ErrorClassOf(newQueryError()) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirector_RunErrorClasses(t *testing.T) {
+	t.Setenv("PROC_DISABLE_QUERY_BATCHING", "")
+	db, _ := newFakeDB(t, func(stmnt string) fakeResult {
+		switch {
+		case strings.Contains(stmnt, "fcst_len = 0 "):
+			return fakeResult{err: &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}}
+		case strings.Contains(stmnt, "fcst_len = 1 "):
+			return fakeResult{err: &mysql.MySQLError{Number: 1064, Message: "syntax error"}}
+		case strings.Contains(stmnt, "fcst_len = 2 "):
+			return fakeResult{columns: scalarTestRows(stmnt).columns}
+		}
+		return scalarTestRows(stmnt)
+	})
+	region, queryRegion := scalarTestRegion("0", "1", "2", "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99}
+	var observer countingObserver
+	director.SetCellObserver(&observer)
+	var summary RunSummary
+	err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary)
+	if err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	if observer.done != 4 || observer.errored != 3 {
+		t.Errorf("Run() told the observer about %d cells and %d errors, want 4 and 3", observer.done, observer.errored)
+	}
+	want := map[ErrorClass]int64{ErrorClassMissingTable: 1, ErrorClassSyntax: 1, ErrorClassNoData: 1}
+	counts := summary.ErrorCounts()
+	if len(counts) != len(want) {
+		t.Errorf("Run() error counts %v, want %v", counts, want)
+	}
+	for class, n := range want {
+		if counts[class] != n {
+			t.Errorf("Run() error counts %v, want %v", counts, want)
+		}
+	}
+	if summary.Cells() != 4 {
+		t.Errorf("Run() cells %d, want 4", summary.Cells())
+	}
+	if s := summary.String(); s != "missing_table: 1, no_data: 1, syntax: 1" {
+		t.Errorf("RunSummary.String() = %q", s)
+	}
+	cells := region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	for _, fcst := range []string{"0", "1", "2"} {
+		if cells[fcst] != builder.ErrorValue {
+			t.Errorf("Run() cell %s = %v, want the ErrorValue", fcst, cells[fcst])
+		}
+	}
+}
+
+func TestDirector_RunRetriesTransientErrors(t *testing.T) {
+	t.Setenv("PROC_DISABLE_QUERY_BATCHING", "")
+	var lock sync.Mutex
+	attempts := map[string]int{}
+	db, _ := newFakeDB(t, func(stmnt string) fakeResult {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts[stmnt]++
+		switch {
+		case strings.Contains(stmnt, "fcst_len = 0 ") && attempts[stmnt] <= 2:
+			// a deadlock victim twice, then it succeeds
+			return fakeResult{err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}}
+		case strings.Contains(stmnt, "fcst_len = 3 "):
+			// the server keeps dropping the connection
+			return fakeResult{err: &mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"}}
+		}
+		return scalarTestRows(stmnt)
+	})
+	region, queryRegion := scalarTestRegion("0", "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99}
+	director.SetRetryPolicy(retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	var summary RunSummary
+	err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary)
+	if err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	cells := region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	if _, ok := cells["0"].(builder.ValueStruct); !ok {
+		t.Errorf("Run() cell 0 = %v, want a ValueStruct after the retries", cells["0"])
+	}
+	if cells["3"] != builder.ErrorValue {
+		t.Errorf("Run() cell 3 = %v, want the ErrorValue", cells["3"])
+	}
+	if counts := summary.ErrorCounts(); len(counts) != 1 || counts[ErrorClassConnectionLost] != 1 {
+		t.Errorf("Run() error counts %v, want one connection_lost", counts)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	for stmnt, n := range attempts {
+		want := 1
+		switch {
+		case strings.Contains(stmnt, "fcst_len = 0 "):
+			want = 3
+		case strings.Contains(stmnt, "fcst_len = 3 "):
+			// the experimental query isn't run without control data
+			if !strings.Contains(stmnt, "HRRR_OPS") {
+				t.Errorf("Run() ran the experimental query of a cell without control data")
+			}
+			want = 3
+		}
+		if n != want {
+			t.Errorf("Run() ran %d attempts of %q, want %d", n, stmnt, want)
+		}
+	}
+}
+
+// countingObserver counts the cells that a director finishes
+type countingObserver struct {
+	lock    sync.Mutex
+	done    int
+	errored int
+}
+
+func (o *countingObserver) CellDone(_ scorecard.CellKeys, errorClass ErrorClass) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.done++
+	if errorClass != "" {
+		o.errored++
+	}
+}
@@ -0,0 +1,159 @@
+package director
+
+/*
+Query cost analysis.
+
+Some query templates scan multi-billion-row tables without an index, and a scorecard that runs
+them for every cell can take down the shared MySQL server. Before a scorecard is processed the
+manager can have a director EXPLAIN every distinct rendered statement. The plan of each table a
+statement reads is checked for a full table scan (EXPLAIN type ALL) and for more estimated rows
+than a limit. Full scans of small tables are cheap and are not flagged.
+
+A statement that can't be EXPLAINed (e.g. its table doesn't exist) is only logged, the same
+statement fails its cell when the scorecard is processed.
+*/
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultExplainMaxRows is the estimated number of rows of a table above which a query is flagged
+const DefaultExplainMaxRows = 100000000
+
+// full scans of tables with no more estimated rows than this are not flagged
+const explainSmallTableRows = 10000
+
+// QueryCostFinding is a table in the EXPLAIN plan of a statement that is too expensive to read
+type QueryCostFinding struct {
+	Cell          string   `json:"cell,omitempty"` // the path of a cell that uses the statement
+	Statement     string   `json:"statement"`
+	Table         string   `json:"table"`
+	AccessType    string   `json:"access_type"`   // the EXPLAIN type e.g. ALL for a full table scan
+	Key           string   `json:"key,omitempty"` // the index that is used, if any
+	EstimatedRows int64    `json:"estimated_rows"`
+	Problems      []string `json:"problems"`
+}
+
+// explainRow is the part of an EXPLAIN row that is checked
+type explainRow struct {
+	table      string
+	accessType string
+	key        string
+	rows       int64
+}
+
+// explain runs EXPLAIN for a statement and returns a row for each table in the plan
+func (director *Director) explain(ctx context.Context, stmnt string) ([]explainRow, error) {
+	ctx, cancel := director.queryContext(ctx)
+	defer cancel()
+	rows, err := director.db.QueryContext(ctx, "EXPLAIN "+stmnt)
+	if err != nil {
+		return nil, newQueryError(stmnt, fmt.Errorf("mysql_director explain Query failed: %w", err))
+	}
+	defer rows.Close()
+	// the columns depend on the server version, so they are found by name
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, newQueryError(stmnt, fmt.Errorf("mysql_director explain error getting columns %w", err))
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var plan []explainRow
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, newQueryError(stmnt, fmt.Errorf("mysql_director explain error reading row %w", err))
+		}
+		var row explainRow
+		for i, column := range columns {
+			switch strings.ToLower(column) {
+			case "table":
+				row.table = values[i].String
+			case "type":
+				row.accessType = values[i].String
+			case "key":
+				row.key = values[i].String
+			case "rows":
+				// NULL for plans that don't read a table
+				row.rows, _ = strconv.ParseInt(values[i].String, 10, 64)
+			}
+		}
+		plan = append(plan, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, newQueryError(stmnt, fmt.Errorf("mysql_director explain error reading rows %w", err))
+	}
+	return plan, nil
+}
+
+// costFindings checks the plan of a statement
+func costFindings(stmnt string, plan []explainRow, maxRows int64) []QueryCostFinding {
+	var findings []QueryCostFinding
+	for _, row := range plan {
+		if row.table == "" {
+			continue
+		}
+		var problems []string
+		if row.accessType == "ALL" && row.rows > explainSmallTableRows {
+			problems = append(problems, "full table scan without an index")
+		}
+		if row.rows > maxRows {
+			problems = append(problems, fmt.Sprintf("estimated %d rows, more than the limit of %d", row.rows, maxRows))
+		}
+		if len(problems) > 0 {
+			findings = append(findings, QueryCostFinding{
+				Statement:     stmnt,
+				Table:         row.table,
+				AccessType:    row.accessType,
+				Key:           row.key,
+				EstimatedRows: row.rows,
+				Problems:      problems,
+			})
+		}
+	}
+	return findings
+}
+
+// ExplainQueries EXPLAINs the statements in the director's pool of workers and returns the findings for the
+// tables that are too expensive to read, in the order of the statements. It only returns an error if ctx is done.
+func (director *Director) ExplainQueries(ctx context.Context, stmnts []string, maxRows int64) ([]QueryCostFinding, error) {
+	results := make([][]QueryCostFinding, len(stmnts))
+	errGroup, groupCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(max(director.workers, 1))
+	for i, stmnt := range stmnts {
+		errGroup.Go(func() error {
+			plan, err := retry.Value(groupCtx, director.retryPolicy, "mysql_explain", isTransientQueryError,
+				func(ctx context.Context) ([]explainRow, error) {
+					return director.explain(ctx, stmnt)
+				})
+			if err != nil {
+				if groupCtx.Err() != nil {
+					return fmt.Errorf("mysql_director ExplainQueries stopped: %w", context.Cause(groupCtx))
+				}
+				log.Printf("mysql_director ExplainQueries %s error: %v", ErrorClassOf(err), err)
+				return nil
+			}
+			results[i] = costFindings(stmnt, plan, maxRows)
+			return nil
+		})
+	}
+	if err := errGroup.Wait(); err != nil {
+		return nil, err
+	}
+	var findings []QueryCostFinding
+	for _, r := range results {
+		findings = append(findings, r...)
+	}
+	expensiveQueries.Add(float64(len(findings)))
+	return findings, nil
+}
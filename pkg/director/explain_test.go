@@ -0,0 +1,76 @@
+package director
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// explainTestResult is an EXPLAIN result in the MySQL 8 format with a row for each table
+func explainTestResult(tables ...[]driver.Value) fakeResult {
+	result := fakeResult{columns: []string{"id", "select_type", "table", "partitions", "type", "possible_keys", "key", "key_len", "ref", "rows", "filtered", "Extra"}}
+	for _, t := range tables {
+		// table, type, key, rows
+		result.rows = append(result.rows, []driver.Value{int64(1), "SIMPLE", t[0], nil, t[1], nil, t[2], nil, nil, t[3], 100.0, nil})
+	}
+	return result
+}
+
+func TestDirector_ExplainQueries(t *testing.T) {
+	db, fds := newFakeDB(t, func(stmnt string) fakeResult {
+		switch {
+		case strings.Contains(stmnt, "FROM big"):
+			return explainTestResult([]driver.Value{"m0", "ALL", nil, int64(2000000000)})
+		case strings.Contains(stmnt, "FROM indexed"):
+			return explainTestResult([]driver.Value{"m0", "range", "PRIMARY", int64(200000)})
+		case strings.Contains(stmnt, "FROM small"):
+			return explainTestResult([]driver.Value{"m0", "ALL", nil, int64(40)})
+		case strings.Contains(stmnt, "FROM wide"):
+			return explainTestResult([]driver.Value{"m0", "ref", "valid_day", int64(5000000)}, []driver.Value{"o", "ALL", nil, int64(800000)})
+		case strings.Contains(stmnt, "FROM missing"):
+			return fakeResult{err: &mysql.MySQLError{Number: 1146, Message: "Table 'missing' doesn't exist"}}
+		default:
+			return explainTestResult([]driver.Value{nil, nil, nil, nil})
+		}
+	})
+	director := &Director{db: db, workers: 2}
+	stmnts := []string{"SELECT 1 FROM big", "SELECT 1 FROM indexed", "SELECT 1 FROM small", "SELECT 1 FROM wide", "SELECT 1 FROM missing", "SELECT 1"}
+	findings, err := director.ExplainQueries(context.Background(), stmnts, 1000000)
+	if err != nil {
+		t.Fatalf("ExplainQueries() error %v", err)
+	}
+	want := []QueryCostFinding{
+		{Statement: "SELECT 1 FROM big", Table: "m0", AccessType: "ALL", EstimatedRows: 2000000000,
+			Problems: []string{"full table scan without an index", "estimated 2000000000 rows, more than the limit of 1000000"}},
+		{Statement: "SELECT 1 FROM wide", Table: "m0", AccessType: "ref", Key: "valid_day", EstimatedRows: 5000000,
+			Problems: []string{"estimated 5000000 rows, more than the limit of 1000000"}},
+		{Statement: "SELECT 1 FROM wide", Table: "o", AccessType: "ALL", EstimatedRows: 800000,
+			Problems: []string{"full table scan without an index"}},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("ExplainQueries() = %+v, want %+v", findings, want)
+	}
+	for _, q := range fds.Queries() {
+		if !strings.HasPrefix(q, "EXPLAIN ") {
+			t.Errorf("ExplainQueries() ran %q", q)
+		}
+	}
+}
+
+func TestDirector_ExplainQueriesCancelled(t *testing.T) {
+	db, _ := newFakeDB(t, func(stmnt string) fakeResult {
+		return explainTestResult([]driver.Value{"m0", "ALL", nil, int64(2000000000)})
+	})
+	director := &Director{db: db, workers: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := director.ExplainQueries(ctx, []string{"SELECT 1 FROM big"}, 1000000)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ExplainQueries() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,127 @@
+package director
+
+/*
+A fake database/sql driver for testing the director without a MySQL server.
+Each test gets its own data source whose handler decides what every statement returns.
+*/
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	delay   time.Duration // how long the statement takes - it is interrupted by context cancellation
+}
+
+type fakeDataSource struct {
+	lock    sync.Mutex
+	handler func(stmnt string) fakeResult
+	queries []string
+}
+
+// Queries returns the statements that have been run so far
+func (fds *fakeDataSource) Queries() []string {
+	fds.lock.Lock()
+	defer fds.lock.Unlock()
+	return append([]string(nil), fds.queries...)
+}
+
+var (
+	fakeDataSources   sync.Map // dsn -> *fakeDataSource
+	fakeDataSourceSeq atomic.Int64
+)
+
+func init() {
+	sql.Register("fakemysql", fakeDriver{})
+}
+
+// newFakeDB returns a *sql.DB whose statements are answered by handler
+func newFakeDB(t *testing.T, handler func(stmnt string) fakeResult) (*sql.DB, *fakeDataSource) {
+	t.Helper()
+	fds := &fakeDataSource{handler: handler}
+	dsn := fmt.Sprintf("fake%d", fakeDataSourceSeq.Add(1))
+	fakeDataSources.Store(dsn, fds)
+	db, err := sql.Open("fakemysql", dsn)
+	if err != nil {
+		t.Fatalf("newFakeDB() error %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDataSources.Delete(dsn)
+	})
+	return db, fds
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fds, ok := fakeDataSources.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("fakemysql unknown data source %q", dsn)
+	}
+	return &fakeConn{fds: fds.(*fakeDataSource)}, nil
+}
+
+type fakeConn struct {
+	fds *fakeDataSource
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakemysql does not support prepared statements")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("fakemysql does not support transactions")
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error { return nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.fds.lock.Lock()
+	c.fds.queries = append(c.fds.queries, query)
+	c.fds.lock.Unlock()
+	result := c.fds.handler(query)
+	if result.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(result.delay):
+		}
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -12,15 +12,18 @@ references to sub maps in the scorecard.
 Each director is controlled by a manager. A manager has as many directors
 as is needed to process Each region within a scorecard block.
 
-The director runs as many scorecard builders in Go routines as are necessary to
-process every scorecard cell within the block / region that the director is assigned.
+The director first enumerates every scorecard cell within the block / region that it is
+assigned and then queries and builds the cells in a bounded pool of Go routines.
 */
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"sync"
+	"time"
 
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
 )
 
 // for couchbase all these fields will be needed
@@ -41,27 +44,47 @@ type DbCredentials struct {
 type ScorecardBlock map[string]any
 
 type Director struct {
-	mysqlCredentials DbCredentials
-	db               *sql.DB
-	queryBlock       ScorecardBlock
-	resultBlock      ScorecardBlock
-	dateRange        DateRange
-	minorThreshold   float64
-	majorThreshold   float64
-	wg               *sync.WaitGroup
-	statistics       []string
-	statisticType    builder.StatisticType
+	db                *sql.DB // borrowed from the manager's ConnectionPool
+	queryBlock        ScorecardBlock
+	resultBlock       ScorecardBlock
+	dateRange         DateRange
+	minorThreshold    float64
+	majorThreshold    float64
+	workers           int           // the number of cells that are processed concurrently
+	queryTimeout      time.Duration // the limit for each query, zero means no limit
+	queryChunk        QueryChunk    // the longest date range of a single query, zero means the whole date range
+	retryPolicy       retry.Policy  // for queries that fail with a transient error
+	templateVariables TemplateVariables
+	queryCache        *QueryCache
+	cellObserver      CellObserver         // told about each cell as it is finished, may be nil
+	exclusions        []builder.TimeWindow // the valid times that the builder drops before matching
+	hourFilter        builder.HourFilter   // the valid and init hours of the matched records that the builder uses
+}
+
+// CellObserver is told about each cell that a director finishes, e.g. to report the progress of a run.
+// The directors call it concurrently.
+type CellObserver interface {
+	// CellDone is called when the value of the cell with the keys is known, errorClass is empty unless the cell failed
+	CellDone(keys scorecard.CellKeys, errorClass ErrorClass)
 }
 
 type DirectorBuilder interface {
 	// datasourceName like user:password@tcp(hostname:3306)/dbname
-	Run(queryRegionName string, regionMap ScorecardBlock, queryMap ScorecardBlock)
-	CloseDB()
-	getMySqlConnection(mysqlCredentials DbCredentials) (*sql.DB, error)
-	queryDataPreCalc(stmnt string) (queryResult builder.PreCalcRecords, err error)
-	queryDataCTC(stmnt string) (queryResult builder.CTCRecords, err error)
-	queryDataScalar(stmnt string) (queryResult builder.ScalarRecords, err error)
-	processSub(queryRegionName string, region interface{}, queryElem interface{}, wgPtr *sync.WaitGroup, cellCountPtr *int, keychain *[]string, dateRange DateRange) (interface{}, error)
+	Run(ctx context.Context, queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion, summary *RunSummary) error
+	ExplainQueries(ctx context.Context, stmnts []string, maxRows int64) ([]QueryCostFinding, error)
+	DryRun(queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion) ([]CellPlan, error)
+	SetWorkers(workers int)
+	SetQueryTimeout(timeout time.Duration)
+	SetQueryChunk(chunk QueryChunk)
+	SetRetryPolicy(policy retry.Policy)
+	SetTemplateVariables(vars TemplateVariables)
+	SetQueryCache(queryCache *QueryCache)
+	SetCellObserver(observer CellObserver)
+	queryDataPreCalc(ctx context.Context, stmnt string) (queryResult builder.PreCalcRecords, err error)
+	queryDataCTC(ctx context.Context, stmnt string) (queryResult builder.CTCRecords, err error)
+	queryDataScalar(ctx context.Context, stmnt string) (queryResult builder.ScalarRecords, err error)
+	enumerateCells(queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion) ([]*cell, error)
+	processCell(ctx context.Context, c *cell) (interface{}, error)
 }
 
 type DateRange struct {
@@ -69,10 +92,11 @@ type DateRange struct {
 	ToSecs   int64
 }
 
-// GetDirector returns a correctly initizalized director. Callers should make sure to call Close() when they're done with the director.
-func GetDirector(directorType string, mysqlCredentials DbCredentials, dateRange DateRange, minorThreshold float64, majorThreshold float64) (*Director, error) {
+// GetDirector returns a correctly initizalized director. The director borrows its connections from pool,
+// the caller owns the pool and closes it when all of its directors are done.
+func GetDirector(directorType string, pool *ConnectionPool, dateRange DateRange, minorThreshold float64, majorThreshold float64) (*Director, error) {
 	if directorType == "MysqlDirector" {
-		return newMySQLDirector(mysqlCredentials, dateRange, minorThreshold, majorThreshold)
+		return newMySQLDirector(pool, dateRange, minorThreshold, majorThreshold)
 	} else {
 		return nil, fmt.Errorf("Director GetDirector unsupported directorType: %q", directorType)
 	}
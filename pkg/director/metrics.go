@@ -0,0 +1,54 @@
+package director
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var subsystem = "director"
+
+var (
+	queryCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "query_cache_hits_total",
+			Help:      "Number of cell queries that were answered by the per-run query cache.",
+		},
+	)
+
+	queryCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "query_cache_misses_total",
+			Help:      "Number of cell queries that had to be run against the database.",
+		},
+	)
+	batchedQueries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "batched_queries_total",
+			Help:      "Number of grouped queries that fetched the data for several cells at once.",
+		},
+	)
+
+	cellErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "cell_errors_total",
+			Help:      "Number of scorecard cells without a value, by error class.",
+		},
+		[]string{"class"},
+	)
+
+	expensiveQueries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "expensive_query_findings_total",
+			Help:      "Number of tables in the EXPLAIN plans of queries that were flagged as too expensive to read.",
+		},
+	)
+
+	// connectionPools exports the in use connections and the wait statistics of the open connection pools
+	connectionPools = newPoolCollector()
+)
+
+func init() {
+	prometheus.MustRegister(queryCacheHits, queryCacheMisses, batchedQueries, cellErrors, expensiveQueries, connectionPools)
+}
@@ -27,73 +27,53 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
 	_ "github.com/go-sql-driver/mysql"
+	"golang.org/x/sync/errgroup"
 )
 
-const (
-	noTableFound   = "Error 1146 (42S02)"
-	convertingNull = "converting NULL"
-)
+// DefaultWorkers is the number of cells a director processes concurrently if SetWorkers isn't used
+const DefaultWorkers = 10
 
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
-
-// getMySQLConnection establishes a connection to the given SQL database
-// connection strings should be like: user:password@tcp(localhost:5555)
-func (director *Director) getMySqlConnection(mysqlCredentials DbCredentials) (*sql.DB, error) {
-	// get the connection
-	driver := "mysql"
-	dataSource := fmt.Sprintf("%s:%s@tcp(%s)/", mysqlCredentials.User, mysqlCredentials.Password, mysqlCredentials.Host)
-	var db *sql.DB
-	db, err := sql.Open(driver, dataSource)
-	db.SetConnMaxLifetime(time.Minute * 3)
-	db.SetMaxOpenConns(20)
-	db.SetMaxIdleConns(10)
-	if err != nil {
-		return nil, fmt.Errorf("mysql_director getMySqlConnection sql open error %w", err)
+// newMySQLDirector creates a correctly initialized MySQL director that borrows connections from pool.
+// GetDirector should be used by clients instead of this.
+func newMySQLDirector(pool *ConnectionPool, dateRange DateRange, minorThreshold, majorThreshold float64) (*Director, error) {
+	if pool == nil {
+		return nil, fmt.Errorf("mysql_director NewMysqlDirector error: no connection pool")
 	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelfunc()
-	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("mysql_director Build sql open/ping error: %w", err)
+	mysqlDirector := Director{
+		db:             pool.db,
+		queryBlock:     ScorecardBlock{},
+		resultBlock:    ScorecardBlock{},
+		dateRange:      dateRange,
+		minorThreshold: minorThreshold,
+		majorThreshold: majorThreshold,
+		workers:        DefaultWorkers,
 	}
-	return db, nil
+	return &mysqlDirector, nil
 }
 
-// newMySQLDirector creates a correctly initialized MySQL director. GetDirector should be used by clients instead of this.
-func newMySQLDirector(mysqlCredentials DbCredentials, dateRange DateRange, minorThreshold, majorThreshold float64) (*Director, error) {
-	mysqlDirector := Director{}
-	db, err := mysqlDirector.getMySqlConnection(mysqlCredentials)
-	if err != nil {
-		return nil, fmt.Errorf("mysql_director NewMysqlDirector error: %w", err)
-	} else {
-		mysqlDirector.db = db
-		mysqlDirector.mysqlCredentials = mysqlCredentials
-		mysqlDirector.queryBlock = ScorecardBlock{}
-		mysqlDirector.resultBlock = ScorecardBlock{}
-		mysqlDirector.dateRange = dateRange
-		mysqlDirector.minorThreshold = minorThreshold
-		mysqlDirector.majorThreshold = majorThreshold
-		mysqlDirector.wg = &sync.WaitGroup{}
+// queryContext bounds a single query by the director's query timeout, if it has one
+func (director *Director) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if director.queryTimeout > 0 {
+		return context.WithTimeout(ctx, director.queryTimeout)
 	}
-	return &mysqlDirector, nil
+	return context.WithCancel(ctx)
 }
 
-func (director *Director) queryDataPreCalc(stmnt string) (queryResult builder.PreCalcRecords, err error) {
+func (director *Director) queryDataPreCalc(ctx context.Context, stmnt string) (queryResult builder.PreCalcRecords, err error) {
+	ctx, cancel := director.queryContext(ctx)
+	defer cancel()
 	var rows *sql.Rows
-	rows, err = director.db.Query(stmnt)
+	rows, err = director.db.QueryContext(ctx, stmnt)
 	if err != nil {
-		err = fmt.Errorf("mysql_director queryData Query failed: %w", err)
+		err = newQueryError(stmnt, fmt.Errorf("mysql_director queryData Query failed: %w", err))
 		return queryResult, err
 	}
 	defer rows.Close()
@@ -101,20 +81,25 @@ func (director *Director) queryDataPreCalc(stmnt string) (queryResult builder.Pr
 	for rows.Next() {
 		err = rows.Scan(&record.Avtime, &record.Stat)
 		if err != nil {
-			err = fmt.Errorf("mysqlDirector.Query error reading PreCalcRecord row %w", err)
+			err = newQueryError(stmnt, fmt.Errorf("mysqlDirector.Query error reading PreCalcRecord row %w", err))
 			return queryResult, err
 		} else {
 			queryResult = append(queryResult, record)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return queryResult, newQueryError(stmnt, fmt.Errorf("mysql_director queryData error reading rows %w", err))
+	}
 	return queryResult, nil
 }
 
-func (director *Director) queryDataCTC(stmnt string) (queryResult builder.CTCRecords, err error) {
+func (director *Director) queryDataCTC(ctx context.Context, stmnt string) (queryResult builder.CTCRecords, err error) {
+	ctx, cancel := director.queryContext(ctx)
+	defer cancel()
 	var rows *sql.Rows
-	rows, err = director.db.Query(stmnt)
+	rows, err = director.db.QueryContext(ctx, stmnt)
 	if err != nil {
-		err = fmt.Errorf("mysql_director queryData Query failed: %w", err)
+		err = newQueryError(stmnt, fmt.Errorf("mysql_director queryData Query failed: %w", err))
 		return queryResult, err
 	}
 	defer rows.Close()
@@ -122,21 +107,25 @@ func (director *Director) queryDataCTC(stmnt string) (queryResult builder.CTCRec
 	for rows.Next() {
 		err = rows.Scan(&record.Avtime, &record.Hit, &record.Miss, &record.Fa, &record.Cn)
 		if err != nil {
-			err = fmt.Errorf("mysqlDirector.Query error reading CTCRecord row %w", err)
+			err = newQueryError(stmnt, fmt.Errorf("mysqlDirector.Query error reading CTCRecord row %w", err))
 			return queryResult, err
 		} else {
 			queryResult = append(queryResult, record)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return queryResult, newQueryError(stmnt, fmt.Errorf("mysql_director queryData error reading rows %w", err))
+	}
 	return queryResult, nil
 }
 
-// func queryDataScalar(stmnt string, queryResult builder.ScalarRecords) (err error) {
-func (director *Director) queryDataScalar(stmnt string) (queryResult builder.ScalarRecords, err error) {
+func (director *Director) queryDataScalar(ctx context.Context, stmnt string) (queryResult builder.ScalarRecords, err error) {
+	ctx, cancel := director.queryContext(ctx)
+	defer cancel()
 	var rows *sql.Rows
-	rows, err = director.db.Query(stmnt)
+	rows, err = director.db.QueryContext(ctx, stmnt)
 	if err != nil {
-		err = fmt.Errorf("mysql_director queryData Query failed: %w", err)
+		err = newQueryError(stmnt, fmt.Errorf("mysql_director queryData Query failed: %w", err))
 		return queryResult, err
 	}
 	defer rows.Close()
@@ -144,277 +133,273 @@ func (director *Director) queryDataScalar(stmnt string) (queryResult builder.Sca
 	for rows.Next() {
 		err = rows.Scan(&record.Avtime, &record.SquareDiffSum, &record.NSum, &record.ObsModelDiffSum, &record.ModelSum, &record.ObsSum, &record.AbsSum)
 		if err != nil {
-			err = fmt.Errorf("mysqlDirector.Query error reading ScalarRecord row %w", err)
+			err = newQueryError(stmnt, fmt.Errorf("mysqlDirector.Query error reading ScalarRecord row %w", err))
 			return queryResult, err
 		} else {
 			queryResult = append(queryResult, record)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return queryResult, newQueryError(stmnt, fmt.Errorf("mysql_director queryData error reading rows %w", err))
+	}
 	return queryResult, nil
 }
 
-// used to return value and err from go routines
-type errval struct {
-	err error
-	val int
+// a cell is a leaf of a region, i.e. one scorecard cell
+type cell struct {
+	keychain      []string
+	keys          scorecard.CellKeys // the keys of the cell below its region
+	queryLeaf     scorecard.QueryLeaf
+	statisticType builder.StatisticType
+	value         interface{} // either a builder.ValueStruct or builder.ErrorValue
+	errorClass    ErrorClass  // why the value is a builder.ErrorValue, empty if it isn't
 }
 
-var singleThreadedDirector bool = false
+// queryDataType decides what kind of records a statement returns by the columns it selects
+func queryDataType(stmnt string) string {
+	switch {
+	case strings.Contains(stmnt, "hit"):
+		return "CTC"
+	case strings.Contains(stmnt, "square_diff_sum"):
+		return "Scalar"
+	case strings.Contains(stmnt, "stat"):
+		return "PreCalc"
+	default:
+		return ""
+	}
+}
 
-// Recursively process a region/Block until all the leaves (which are cells) have been traversed and processed
-func (director *Director) processSub(queryRegionName string, region interface{}, queryElem interface{}, cellCountPtr *int, keychain *[]string, dateRange DateRange) (interface{}, error) {
-	var err error
-	keys := getMapKeys(queryElem.(map[string]interface{}))
-	thisIsALeaf := false
+// enumerateCells collects the cells of a region with their query templates.
+// Nothing is queried or modified here, the cells are processed afterwards by the worker pool in Run.
+func (director *Director) enumerateCells(queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion) ([]*cell, error) {
+	keys := region.Keys()
+	cells := make([]*cell, 0, len(keys))
 	for _, k := range keys {
-		if k == "controlQueryTemplate" {
-			thisIsALeaf = true
-			break
+		queryLeaf, ok := queryRegion.Get(k)
+		if !ok {
+			return cells, fmt.Errorf("mysql_director enumerateCells queryMap has no element %q", k.Path(queryRegionName))
 		}
+		cells = append(cells, &cell{
+			keychain:      k.Keychain(queryRegionName),
+			keys:          k,
+			queryLeaf:     queryLeaf,
+			statisticType: builder.GetStatisticTpe(k.Statistic),
+		})
 	}
-	if thisIsALeaf { // now we have a struct
-		// log statement uncomment for debugging
-		// log.Printf("mysql_director processSub leaf keys are %q", keys)
+	return cells, nil
+}
 
-		// get the queries
-		var ctlQueryStatement string = queryElem.(map[string]interface{})["controlQueryTemplate"].(string)
-		var expQueryStatement string = queryElem.(map[string]interface{})["experimentalQueryTemplate"].(string)
-		// substitute the {{fromSecs}} and {{toSecs}}
-		ctlQueryStatement = strings.Replace(ctlQueryStatement, "{{fromSecs}}", fmt.Sprint(director.dateRange.FromSecs), -1)
-		ctlQueryStatement = strings.Replace(ctlQueryStatement, "{{toSecs}}", fmt.Sprint(director.dateRange.ToSecs), -1)
-		expQueryStatement = strings.Replace(expQueryStatement, "{{fromSecs}}", fmt.Sprint(director.dateRange.FromSecs), -1)
-		expQueryStatement = strings.Replace(expQueryStatement, "{{toSecs}}", fmt.Sprint(director.dateRange.ToSecs), -1)
-		var queryResult interface{}
-		queryError := false
+// queryBoth runs the control and then the experimental statements of a cell (through the query cache).
+// There is a statement for each chunk of the date range. The experimental statements are not run if there is no control data.
+func queryBoth[S ~[]R, R any](ctx context.Context, director *Director, ctlStmnts, expStmnts []string, query func(context.Context, string) (S, error),
+	avtime func(R) int64, combine func(R, R) (R, error),
+) (ctlData, expData S, err error) {
+	ctlData, err = queryChunks(ctx, director, ctlStmnts, query, avtime, combine)
+	if err != nil || len(ctlData) == 0 {
+		return ctlData, nil, err
+	}
+	expData, err = queryChunks(ctx, director, expStmnts, query, avtime, combine)
+	return ctlData, expData, err
+}
 
-		// what kind of data?
-		if strings.Contains(ctlQueryStatement, "hit") {
-			// get the data
-			ctlQueryResult, err := director.queryDataCTC(ctlQueryStatement)
-			if len(ctlQueryResult) == 0 && err == nil {
-				// no data is ok, but no need to go on either
-				return builder.ErrorValue, nil
-			}
-			if err != nil {
-				queryError = true
-				if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-					log.Printf("mysql_director queryDataCTC ctlQueryStatement error %q", err)
-				}
-			} else {
-				expQueryResult, err := director.queryDataCTC(expQueryStatement)
-				if len(expQueryResult) == 0 && err == nil {
-					// no data is ok, but no need to go on either
-					return builder.ErrorValue, nil
-				}
-				// handle error
-				if err != nil {
-					queryError = true
-					if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-						log.Printf("mysql_director queryDataCTC expQueryStatement error %q", err)
-					}
-				} else {
-					queryResult = builder.BuilderCTCResult{CtlData: ctlQueryResult, ExpData: expQueryResult}
-				}
-			}
-		} else if strings.Contains(ctlQueryStatement, "square_diff_sum") {
-			// get the data
-			ctlQueryResult, err := director.queryDataScalar(ctlQueryStatement)
-			if len(ctlQueryResult) == 0 && err == nil {
-				// no data is ok, but no need to go on either
-				return builder.ErrorValue, nil
-			}
-			// handle error
-			if err != nil {
-				queryError = true
-				if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-					log.Printf("mysql_director queryDataScalar ctlQueryStatement error %q", err)
-				}
-			} else {
-				expQueryResult, err := director.queryDataScalar(expQueryStatement)
-				if len(expQueryResult) == 0 && err == nil {
-					// no data is ok, but no need to go on either
-					return builder.ErrorValue, nil
-				}
-				// handle error
-				if err != nil {
-					queryError = true
-					if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-						log.Printf("mysql_director queryDataScalar expQueryStatement error %q", err)
-					}
-				} else {
-					queryResult = builder.BuilderScalarResult{CtlData: ctlQueryResult, ExpData: expQueryResult}
-				}
-			}
-		} else if strings.Contains(ctlQueryStatement, "stat") {
-			// get the data
-			ctlQueryResult, err := director.queryDataPreCalc(ctlQueryStatement)
-			if len(ctlQueryResult) == 0 && err == nil {
-				// no data is ok, but no need to go on either
-				return builder.ErrorValue, nil
-			}
-			// handle error
-			if err != nil {
-				queryError = true
-				if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-					log.Printf("mysql_director queryDataPreCalc ctlQueryStatement error %q", err)
-				}
-			} else {
-				expQueryResult, err := director.queryDataPreCalc(expQueryStatement)
-				if len(expQueryResult) == 0 && err == nil {
-					// no data is ok, but no need to go on either
-					return builder.ErrorValue, nil
-				}
-				if err != nil {
-					queryError = true
-					if !strings.Contains(err.Error(), noTableFound) && !strings.Contains(err.Error(), convertingNull) {
-						log.Printf("mysql_director queryDataPreCalc expQueryStatement error %q", err)
-					}
-				} else {
-					queryResult = builder.BuilderPreCalcResult{CtlData: ctlQueryResult, ExpData: expQueryResult}
-				}
-			}
-		} else {
-			// unknown data type
-			return builder.ErrorValue, fmt.Errorf("mysql_director processSub error unknown data type - ctlQueryStatement %s - %w", ctlQueryStatement, err)
-		}
+func ctcAvtime(r builder.CTCRecord) int64         { return r.Avtime }
+func scalarAvtime(r builder.ScalarRecord) int64   { return r.Avtime }
+func preCalcAvtime(r builder.PreCalcRecord) int64 { return r.Avtime }
 
-		// for all the input elements
-		// build the input data elements - derive the statistic and summary value
-		// for this element i.e. this cell in the scorecard.
-		// The actual scorecard data location for the cell is written in
-		// the branch part of processSub when it encounters a leaf. See below.
-		// Build(qr QueryResult, statisticType string, dataType string
-		if queryError {
-			if err != nil {
-				log.Printf("mysql_director query error %v", err)
-			}
-			return builder.ErrorValue, err
-		} else {
-			valueStruct := builder.ValueStruct{}
-			if !singleThreadedDirector {
-				director.wg.Add(1)
-				// run builder in parallel
-				c := make(chan errval)
-				go func(regionName string) {
-					defer director.wg.Done()
-					*cellCountPtr++
-					scc := builder.NewTwoSampleTTestBuilder()
-					_ = scc.SetKeyChain(*keychain) // ignore errorscc.
-					value, err := (scc.Build(queryResult, director.statisticType, director.minorThreshold, director.majorThreshold))
-					// remove this leaf key from the keychain
-					if len(*keychain) > 0 {
-						kc := *keychain
-						kc = kc[:len(kc)-1]
-						*keychain = kc
-					}
-					c <- errval{err: err, val: value}
-					// build the value structure for this cell
-					valueStruct.Path = scc.GetPath()
-					valueStruct.GoodnessPolarity = scc.GetGoodnessPolarity()
-					valueStruct.MajorThreshold = scc.GetMajorThreshold()
-					valueStruct.MinorThreshold = scc.GetMinorThreshold()
-					valueStruct.StatisticType = fmt.Sprint(scc.GetStatisticType())
-					valueStruct.Pvalue = scc.GetPvalue()
-				}(queryRegionName)
-				ret := <-c
-				if ret.err != nil {
-					return builder.ErrorValue, fmt.Errorf("mysql_director processSub error from builder %w", ret.err)
-				}
-				valueStruct.Value = ret.val
-				return valueStruct, nil
-			} else {
-				// singleThreadedDirector
-				*cellCountPtr++
-				scc := builder.NewTwoSampleTTestBuilder()
-				_ = scc.SetKeyChain(*keychain) // ignore error
-				value, err := (scc.Build(queryResult, director.statisticType, director.minorThreshold, director.majorThreshold))
-				// build the value structure for this cell
-				valueStruct.Path = scc.GetPath()
-				valueStruct.GoodnessPolarity = scc.GetGoodnessPolarity()
-				valueStruct.MajorThreshold = scc.GetMajorThreshold()
-				valueStruct.MinorThreshold = scc.GetMinorThreshold()
-				valueStruct.StatisticType = fmt.Sprint(scc.GetStatisticType())
-				valueStruct.Pvalue = scc.GetPvalue()
-				valueStruct.Value = value
+// renderCellQueries renders the control and experimental statements of a cell for each chunk of the date range
+func (director *Director) renderCellQueries(c *cell) (ctlStmnts, expStmnts []string, err error) {
+	for _, chunk := range dateChunks(director.dateRange, director.queryChunk) {
+		ctlStmnt, expStmnt, err := director.renderLeafQueries(c.keychain, c.queryLeaf, chunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctlStmnts = append(ctlStmnts, ctlStmnt)
+		expStmnts = append(expStmnts, expStmnt)
+	}
+	return ctlStmnts, expStmnts, nil
+}
 
-				// remove this leaf key from the keychain
-				if len(*keychain) > 0 {
-					kc := *keychain
-					kc = kc[:len(kc)-1]
-					*keychain = kc
-				}
-				if err != nil {
-					return builder.ErrorValue, fmt.Errorf("mysql_director processSub error from builder %w", err)
-				}
-				return valueStruct, nil
-			}
+// processCell queries the data for a cell and builds its value. It is safe to call concurrently for
+// different cells - it does not modify the region. A query that fails or times out leaves the cell
+// with an ErrorValue and records the class of the error in the cell, but if ctx is done the error
+// is returned so that the whole run stops.
+func (director *Director) processCell(ctx context.Context, c *cell) (interface{}, error) {
+	path := strings.Join(c.keychain, " -> ")
+	ctlStatements, expStatements, err := director.renderCellQueries(c)
+	if err != nil {
+		return builder.ErrorValue, fmt.Errorf("mysql_director processCell error rendering query templates - %w", err)
+	}
+	// what kind of data?
+	var queryResult interface{}
+	var queryErr error
+	switch queryDataType(ctlStatements[0]) {
+	case "CTC":
+		ctlData, expData, err := queryBoth(ctx, director, ctlStatements, expStatements, director.queryDataCTC, ctcAvtime, combineCTC)
+		if err == nil && len(expData) > 0 {
+			queryResult = builder.BuilderCTCResult{CtlData: ctlData, ExpData: expData}
 		}
-	} else {
-		// This is a branch (not a leaf) so we keep traversing until we get a leaf.
-		// Uncomment this log statement for debugging
-		// 	log.Printf("mysql_director processSub branch keys are %q", keys)
-		// This is a branch (not a leaf) so we keep traversing until we get to a leaf and then
-		// we write the scorecard data with the value from the leaf.
-		// Check to see if this is a statistic elem, so we can set the statisticType
-		var keys []string = getMapKeys((region).(map[string]interface{}))
-		for _, elemKey := range keys {
-			for _, s := range director.statistics {
-				if elemKey == fmt.Sprint(s) {
-					statisticType := builder.GetStatisticTpe(elemKey)
-					director.statisticType = statisticType
-					break
-				}
-			}
-			*keychain = append(*keychain, elemKey)
-			queryElem := queryElem.(map[string]interface{})[elemKey]
-			// Here we are in a for loop of region elements. We pass each region element (sub region) to the recursive
-			// call to processSub. This is the traversal.
-			// Each region element is a sub region - like a variable or a stat etc.
-			// We update the region with the result of the recursive call - If the sub region is a branch the effect is that we
-			// assign the region ptr back to itself, but if it is a leaf element this assigns the value structure ptr
-			// into the document result map. The value structure will either be a structure or it will be an integer value (-9999).
-			region.(map[string]interface{})[elemKey], err = director.processSub(queryRegionName, region.(map[string]interface{})[elemKey], queryElem, cellCountPtr, keychain, dateRange)
-			// remove this branch key from the keychain
-			if len(*keychain) > 0 {
-				kc := *keychain
-				kc = kc[:len(kc)-1]
-				*keychain = kc
-			}
+		queryErr = err
+	case "Scalar":
+		ctlData, expData, err := queryBoth(ctx, director, ctlStatements, expStatements, director.queryDataScalar, scalarAvtime, combineScalar)
+		if err == nil && len(expData) > 0 {
+			queryResult = builder.BuilderScalarResult{CtlData: ctlData, ExpData: expData}
+		}
+		queryErr = err
+	case "PreCalc":
+		ctlData, expData, err := queryBoth(ctx, director, ctlStatements, expStatements, director.queryDataPreCalc, preCalcAvtime, combinePreCalc)
+		if err == nil && len(expData) > 0 {
+			queryResult = builder.BuilderPreCalcResult{CtlData: ctlData, ExpData: expData}
+		}
+		queryErr = err
+	default:
+		// unknown data type
+		return builder.ErrorValue, fmt.Errorf("mysql_director processCell error unknown data type - ctlQueryStatement %s", ctlStatements[0])
+	}
+	if queryErr != nil {
+		if ctx.Err() != nil {
+			return builder.ErrorValue, fmt.Errorf("mysql_director processCell %q stopped: %w", path, context.Cause(ctx))
+		}
+		c.errorClass = ErrorClassOf(queryErr)
+		if !c.errorClass.expected() {
+			log.Printf("mysql_director %s query error for %q: %v", c.errorClass, path, queryErr)
+		}
+		return builder.ErrorValue, nil
+	}
+	if queryResult == nil {
+		// no data is ok, but no need to go on either
+		c.errorClass = ErrorClassNoData
+		return builder.ErrorValue, nil
+	}
 
-			if err != nil {
-				return builder.ErrorValue, err
-			}
+	// build the input data elements - derive the statistic and summary value
+	// for this element i.e. this cell in the scorecard.
+	scc := builder.NewTwoSampleTTestBuilder()
+	_ = scc.SetKeyChain(c.keychain) // ignore error
+	_ = scc.SetExclusions(director.exclusions)
+	var fcstLenSecs int64
+	if len(director.hourFilter.InitHours) > 0 {
+		hours, err := strconv.ParseFloat(c.keys.ForecastLength, 64)
+		if err != nil {
+			// only this cell can't be filtered, the other cells of the region go on
+			c.errorClass = ErrorClassInvalidCell
+			log.Printf("mysql_director %s error for %q: the init hours filter needs a forecast length in hours: %v", c.errorClass, path, err)
+			return builder.ErrorValue, nil
 		}
+		fcstLenSecs = int64(hours * 3600)
 	}
-	return region, nil
+	_ = scc.SetHourFilter(director.hourFilter, fcstLenSecs)
+	value, err := scc.Build(ctx, queryResult, c.statisticType, director.minorThreshold, director.majorThreshold)
+	if err != nil {
+		return builder.ErrorValue, fmt.Errorf("mysql_director processCell error from builder %w", err)
+	}
+	// build the value structure for this cell
+	return builder.ValueStruct{
+		Path:             scc.GetPath(),
+		GoodnessPolarity: scc.GetGoodnessPolarity(),
+		MajorThreshold:   scc.GetMajorThreshold(),
+		MinorThreshold:   scc.GetMinorThreshold(),
+		StatisticType:    fmt.Sprint(scc.GetStatisticType()),
+		Pvalue:           scc.GetPvalue(),
+		Value:            value,
+		Excluded:         scc.GetExcluded(),
+	}, nil
 }
 
-func (director *Director) CloseDB() {
-	director.db.Close()
+// SetQueryCache gives the director a query cache that is shared by all of the directors in a run
+func (director *Director) SetQueryCache(queryCache *QueryCache) {
+	director.queryCache = queryCache
+}
+
+// SetCellObserver sets the observer that the director tells about each cell that it finishes
+func (director *Director) SetCellObserver(observer CellObserver) {
+	director.cellObserver = observer
+}
+
+// SetTemplateVariables sets the scorecard variables that are available to the query templates
+// in addition to the date range and the cell variables. See query_template.go.
+func (director *Director) SetTemplateVariables(vars TemplateVariables) {
+	director.templateVariables = vars
+}
+
+// SetExclusions sets the time windows whose valid times are left out of the statistics of every cell
+func (director *Director) SetExclusions(windows []builder.TimeWindow) {
+	director.exclusions = windows
+}
+
+// SetHourFilter sets the valid and init hours of the matched records that the statistics of every cell use
+func (director *Director) SetHourFilter(filter builder.HourFilter) {
+	director.hourFilter = filter
+}
+
+// SetQueryTimeout bounds each query of the director, zero means the queries are only bounded by the Run context
+func (director *Director) SetQueryTimeout(timeout time.Duration) {
+	director.queryTimeout = timeout
+}
+
+// SetRetryPolicy sets how queries that fail with a transient error (a lost connection or a deadlock) are retried
+func (director *Director) SetRetryPolicy(policy retry.Policy) {
+	director.retryPolicy = policy
+}
+
+// SetWorkers sets the number of cells that the director processes concurrently
+func (director *Director) SetWorkers(workers int) {
+	director.workers = workers
 }
 
 // build a section of a scorecard - this is a region of a block (think vertical slice on the scorecard)
-func (director *Director) Run(queryRegionName string, region interface{}, queryMap map[string]interface{}, cellCountPtr *int) (interface{}, error) {
-	// This is recursive. Recurse down to the cell levl then traverse back up processing
-	// all the cells on the way
-	// get all the statistic strings (they are the keys of the regionMap)
-	director.statistics = getMapKeys((region).(map[string]interface{})) // declared at the top
-	dateRange := director.dateRange
-	// declare a waitgroup so that we can wait for all the stats to finish running - only use it if !singlethreaded
-	// process the regionMap (all the values will be filled in)
-	var keychain []string = make([]string, 0)
-	keychain = append(keychain, queryRegionName)
-	region, err := director.processSub(queryRegionName, region, queryMap, cellCountPtr, &keychain, dateRange)
-	// don't really care what SINGLETHREADEDDIRECTOR env var is set to, just if it is set
-	_, singleThreadedDirector = os.LookupEnv("SINGLETHREADEDDIRECTOR")
-	if !singleThreadedDirector {
-		director.wg.Wait()
-	} else {
-		log.Printf("mysql_director running as SINGLETHREADEDDIRECTOR")
+// When ctx is cancelled or its deadline passes the in-flight queries are cancelled and Run returns the error.
+// The values of the cells are written into region.
+func (director *Director) Run(ctx context.Context, queryRegionName string, region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion, summary *RunSummary) error {
+	// reject templates with unknown or unfilled placeholders before any query is sent
+	if err := director.validateQueryTemplates(queryRegionName, queryRegion); err != nil {
+		return fmt.Errorf("mysql_director error in Run %w", err)
+	}
+	// cells that only differ by forecast length or threshold can share a grouped query
+	if director.queryCache == nil {
+		director.queryCache = NewQueryCache()
+	}
+	// don't really care what PROC_DISABLE_QUERY_BATCHING env var is set to, just if it is set
+	if _, noBatching := os.LookupEnv("PROC_DISABLE_QUERY_BATCHING"); !noBatching {
+		director.prefetchBatches(ctx, queryRegionName, queryRegion)
+	}
+	// find all the cells first, then query and build them in a bounded pool of workers
+	cells, err := director.enumerateCells(queryRegionName, region, queryRegion)
+	if err != nil {
+		return fmt.Errorf("mysql_director error in Run %w", err)
+	}
+	workers := director.workers
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+	errGroup, groupCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(workers)
+	for _, c := range cells {
+		errGroup.Go(func() error {
+			if groupCtx.Err() != nil {
+				// don't start any more cells once the run is stopped
+				c.value = builder.ErrorValue
+				return context.Cause(groupCtx)
+			}
+			var err error
+			c.value, err = director.processCell(groupCtx, c)
+			if err == nil && director.cellObserver != nil {
+				director.cellObserver.CellDone(c.keys, c.errorClass)
+			}
+			return err
+		})
+	}
+	err = errGroup.Wait()
+	// write the values into the region - only this goroutine modifies the region maps
+	for _, c := range cells {
+		region.Set(c.keys, c.value)
+		if c.errorClass != "" {
+			summary.addError(c.errorClass)
+		}
 	}
+	summary.addCells(len(cells))
 	if err != nil {
-		return region, fmt.Errorf("mysql_director error in Run %w", err)
+		return fmt.Errorf("mysql_director error in Run %w", err)
 	}
 	// manager will upsert the document
-	return region, nil
+	return nil
 }
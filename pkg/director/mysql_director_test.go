@@ -0,0 +1,233 @@
+package director
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+const scalarTestTemplate = "SELECT m0.valid_day + 3600 * m0.hour AS avtime, SUM(m0.sum2_t) AS square_diff_sum, SUM(m0.N_dt) AS N_sum, " +
+	"SUM(m0.sum_ob_t-m0.sum_model_t) AS obs_model_diff_sum, SUM(m0.sum_model_t) AS model_sum, SUM(m0.sum_ob_t) AS obs_sum, SUM(0) AS abs_sum " +
+	"FROM surface_sums2.MODEL_metar_v2_ALL_HRRR AS m0 WHERE 1=1 AND m0.valid_day+3600*m0.hour >= {{fromSecs}} AND m0.valid_day+3600*m0.hour <= {{toSecs}} " +
+	"AND m0.fcst_len = FCST GROUP BY avtime ORDER BY avtime;"
+
+// scalarTestRegion returns a results region and its queryMap region with a cell for each forecast length
+func scalarTestRegion(fcstLens ...string) (region scorecard.ResultsRegion, queryRegion scorecard.QueryRegion) {
+	region, queryRegion = scorecard.ResultsRegion{}, scorecard.QueryRegion{}
+	for _, fcst := range fcstLens {
+		keys := scorecard.CellKeys{Statistic: "RMSE", Variable: "2m temperature", Threshold: "threshold_NA", Level: "level_NA", ForecastLength: fcst}
+		region.Set(keys, builder.ErrorValue)
+		queryRegion.Set(keys, scorecard.QueryLeaf{
+			ControlQueryTemplate:      strings.NewReplacer("MODEL", "HRRR_OPS", "FCST", fcst).Replace(scalarTestTemplate),
+			ExperimentalQueryTemplate: strings.NewReplacer("MODEL", "RRFS_A", "FCST", fcst).Replace(scalarTestTemplate),
+		})
+	}
+	return region, queryRegion
+}
+
+// scalarTestRows returns a day of hourly scalar sums, the experimental model is a bit worse than the control
+func scalarTestRows(stmnt string) fakeResult {
+	result := fakeResult{columns: []string{"avtime", "square_diff_sum", "N_sum", "obs_model_diff_sum", "model_sum", "obs_sum", "abs_sum"}}
+	scale := 1.0
+	if strings.Contains(stmnt, "RRFS_A") {
+		scale = 1.5
+	}
+	for i := 0; i < 24; i++ {
+		sq := scale * float64(100+(i*37)%50)
+		result.rows = append(result.rows, []driver.Value{int64(3600 * i), sq, 100.0, 1.0, 2.0, 3.0, 0.0})
+	}
+	return result
+}
+
+func TestDirector_Run(t *testing.T) {
+	t.Setenv("PROC_DISABLE_QUERY_BATCHING", "")
+	var inFlight, maxInFlight atomic.Int32
+	db, fds := newFakeDB(t, func(stmnt string) fakeResult {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return scalarTestRows(stmnt)
+	})
+	fcstLens := []string{"0", "1", "2", "3", "6", "9", "12", "15"}
+	region, queryRegion := scalarTestRegion(fcstLens...)
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99, workers: 4}
+
+	var summary RunSummary
+	err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary)
+	if err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	if summary.Cells() != int64(len(fcstLens)) {
+		t.Errorf("Run() cell count %d, want %d", summary.Cells(), len(fcstLens))
+	}
+	if m := maxInFlight.Load(); m < 2 || m > 4 {
+		t.Errorf("Run() had %d queries in flight, want between 2 and the 4 workers", m)
+	}
+	if q := len(fds.Queries()); q != 2*len(fcstLens) {
+		t.Errorf("Run() ran %d queries, want %d", q, 2*len(fcstLens))
+	}
+	cells := region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	for _, fcst := range fcstLens {
+		value, ok := cells[fcst].(builder.ValueStruct)
+		if !ok {
+			t.Errorf("Run() cell %s = %v, want a ValueStruct", fcst, cells[fcst])
+			continue
+		}
+		wantPath := "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> " + fcst
+		if value.Path != wantPath || value.StatisticType != "RMSE" {
+			t.Errorf("Run() cell %s path %q statistic %q", fcst, value.Path, value.StatisticType)
+		}
+		// the experimental errors are bigger so it is significantly worse
+		if value.Value != -2 {
+			t.Errorf("Run() cell %s value %d, want -2", fcst, value.Value)
+		}
+	}
+}
+
+func TestDirector_RunExclusions(t *testing.T) {
+	db, _ := newFakeDB(t, scalarTestRows)
+	region, queryRegion := scalarTestRegion("0", "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99}
+	// hours 6 to 11 of the 24 hourly valid times
+	director.SetExclusions([]builder.TimeWindow{{FromSecs: 6 * 3600, ToSecs: 11 * 3600}})
+	var summary RunSummary
+	if err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary); err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	for fcst, cell := range region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"] {
+		value, ok := cell.(builder.ValueStruct)
+		if !ok || value.Excluded != 6 || value.Value != -2 {
+			t.Errorf("Run() cell %s = %+v, want 6 excluded times and -2", fcst, cell)
+		}
+	}
+}
+
+func TestDirector_RunHourFilter(t *testing.T) {
+	db, _ := newFakeDB(t, scalarTestRows)
+	region, queryRegion := scalarTestRegion("0", "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99}
+	// the 00Z and 12Z valid times of 9Z and 21Z inits are the 3h forecasts
+	director.SetHourFilter(builder.HourFilter{ValidHours: []int{0, 12}, InitHours: []int{9, 21}})
+	var summary RunSummary
+	if err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary); err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	cells := region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	if value, ok := cells["0"].(builder.ValueStruct); !ok || value.Value != builder.ErrorValue {
+		t.Errorf("Run() cell 0 = %+v, want the error value", cells["0"])
+	}
+	if value, ok := cells["3"].(builder.ValueStruct); !ok || value.Value == builder.ErrorValue {
+		t.Errorf("Run() cell 3 = %+v, want a value", cells["3"])
+	}
+
+	// a forecast length that isn't in hours only fails its own cell
+	region, queryRegion = scalarTestRegion("0", "3")
+	allHours := make([]int, 24)
+	for h := range allHours {
+		allHours[h] = h
+	}
+	director.SetHourFilter(builder.HourFilter{InitHours: allHours})
+	cells = region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	cells["later"] = cells["3"]
+	queryLeaves := queryRegion["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	queryLeaves["later"] = queryLeaves["3"]
+	var laterSummary RunSummary
+	if err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &laterSummary); err != nil {
+		t.Fatalf("Run() error %v", err)
+	}
+	if cells["later"] != builder.ErrorValue {
+		t.Errorf("Run() cell later = %+v, want the error value", cells["later"])
+	}
+	for _, fcst := range []string{"0", "3"} {
+		if value, ok := cells[fcst].(builder.ValueStruct); !ok || value.Value == builder.ErrorValue {
+			t.Errorf("Run() cell %s = %+v, want a value", fcst, cells[fcst])
+		}
+	}
+	if got := laterSummary.ErrorCounts()[ErrorClassInvalidCell]; got != 1 {
+		t.Errorf("Run() invalid cells %d, want 1", got)
+	}
+}
+
+func TestDirector_RunMismatchedQueryMap(t *testing.T) {
+	db, _ := newFakeDB(t, scalarTestRows)
+	region, queryRegion := scalarTestRegion("0", "3")
+	delete(queryRegion["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"], "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99}
+	var summary RunSummary
+	err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary)
+	if err == nil || !strings.Contains(err.Error(), "level_NA -> 3") {
+		t.Errorf("Run() error = %v, want a missing queryMap element error", err)
+	}
+}
+
+func TestDirector_RunCancelled(t *testing.T) {
+	db, fds := newFakeDB(t, func(stmnt string) fakeResult {
+		result := scalarTestRows(stmnt)
+		result.delay = time.Minute // a hung query
+		return result
+	})
+	region, queryRegion := scalarTestRegion("0", "1", "2", "3", "6", "9")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99, workers: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for len(fds.Queries()) == 0 {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+	var summary RunSummary
+	start := time.Now()
+	err := director.Run(ctx, "All HRRR domain", region, queryRegion, &summary)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run() took %v to stop after it was cancelled", elapsed)
+	}
+	// the batch and at most one query per worker were started
+	if q := len(fds.Queries()); q > 3 {
+		t.Errorf("Run() started %d queries after it was cancelled", q)
+	}
+}
+
+func TestDirector_RunQueryTimeout(t *testing.T) {
+	t.Setenv("PROC_DISABLE_QUERY_BATCHING", "")
+	db, _ := newFakeDB(t, func(stmnt string) fakeResult {
+		result := scalarTestRows(stmnt)
+		if strings.Contains(stmnt, "fcst_len = 3 ") {
+			result.delay = time.Minute // a hung query
+		}
+		return result
+	})
+	region, queryRegion := scalarTestRegion("0", "3")
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, minorThreshold: 95, majorThreshold: 99, workers: 2, queryTimeout: 50 * time.Millisecond}
+	var summary RunSummary
+	err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary)
+	if err != nil {
+		t.Fatalf("Run() error %v, a query timeout should only empty its cell", err)
+	}
+	cells := region["RMSE"]["2m temperature"]["threshold_NA"]["level_NA"]
+	if _, ok := cells["0"].(builder.ValueStruct); !ok {
+		t.Errorf("Run() cell 0 = %v, want a ValueStruct", cells["0"])
+	}
+	if cells["3"] != builder.ErrorValue {
+		t.Errorf("Run() cell 3 = %v, want the ErrorValue", cells["3"])
+	}
+	if counts := summary.ErrorCounts(); len(counts) != 1 || counts[ErrorClassTimeout] != 1 {
+		t.Errorf("Run() error counts %v, want one timeout", counts)
+	}
+}
@@ -0,0 +1,311 @@
+package director
+
+/*
+Batched queries.
+
+Every cell needs a control and an experimental query, so a row with 20 forecast lengths and
+10 thresholds costs 400 round trips even though the statements only differ by a WHERE value.
+Before the cells of a region are processed the director looks for leaves whose rendered
+statements are identical except for one clause like "m0.fcst_len = 6" whose value is the
+forecastLength (or threshold) of the cell. Those leaves are combined into one grouped statement
+
+	SELECT m0.fcst_len AS batch_key_0, ... WHERE ... m0.fcst_len IN (0,3,6) ... GROUP BY batch_key_0, avtime ...
+
+and the result is split by the batch key into the per-cell record slices, which are put in the
+query cache under each cell's own rendered statement. When the cell is processed its queries
+are cache hits. Statements that can't be grouped safely (subqueries, no GROUP BY, LIMIT, SELECT DISTINCT
+or another SELECT modifier) are left alone. A grouped query is retried like a cell query and anything
+else that goes wrong with it just leaves the cells to query for themselves.
+*/
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// the cell variables that a batch can be grouped by
+var batchVariables = []string{TemplateForecastLength, TemplateThreshold}
+
+var (
+	selectPattern  = regexp.MustCompile(`(?i)\bselect\b`)
+	groupByPattern = regexp.MustCompile(`(?i)\bgroup\s+by\b`)
+	limitPattern   = regexp.MustCompile(`(?i)\blimit\b`)
+	// the key columns go right after SELECT, which isn't valid SQL before a modifier like DISTINCT or
+	// SQL_NO_CACHE, or an optimizer hint
+	selectModifierPattern = regexp.MustCompile(`(?i)\bselect\s+(all|distinct|distinctrow|high_priority|straight_join|sql_\w+)\b|\bselect\s*/\*`)
+)
+
+// batchClause is a "column = value" clause that varies between the members of a batch
+type batchClause struct {
+	variable string
+	column   string
+	quoted   bool
+}
+
+// batchMember is one cell statement that is part of a batch
+type batchMember struct {
+	stmnt  string   // the rendered statement of the cell - the cache key
+	values []string // the value of each batch clause for this cell
+}
+
+type queryBatch struct {
+	skeleton string // the statement with each batch clause replaced by a marker
+	dataType string
+	clauses  []batchClause
+	members  []batchMember
+}
+
+func batchMarker(i int) string {
+	return fmt.Sprintf("\x00batch_%d\x00", i)
+}
+
+// findBatchClause finds the single "column = value" clause of stmnt. It returns ok == false if
+// there are no such clauses or if there is more than one (that would be ambiguous).
+func findBatchClause(stmnt, value string) (start, end int, column string, quoted bool, ok bool) {
+	pattern := regexp.MustCompile(`([A-Za-z_][\w.]*)\s*=\s*('?)` + regexp.QuoteMeta(value) + `('?)`)
+	found := 0
+	for _, m := range pattern.FindAllStringSubmatchIndex(stmnt, -1) {
+		// the value must not be the start of a longer value e.g. 1 in 12 or 0.01 in 0.015
+		if m[1] < len(stmnt) && (isWordChar(stmnt[m[1]]) || stmnt[m[1]] == '.') {
+			continue
+		}
+		// the quotes must match
+		if (m[5] > m[4]) != (m[7] > m[6]) {
+			continue
+		}
+		found++
+		start, end = m[0], m[1]
+		column = stmnt[m[2]:m[3]]
+		quoted = m[5] > m[4]
+	}
+	return start, end, column, quoted, found == 1
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// batchable reports whether a statement can be rewritten into a grouped statement
+func batchable(stmnt string) bool {
+	return len(selectPattern.FindAllStringIndex(stmnt, -1)) == 1 &&
+		len(groupByPattern.FindAllStringIndex(stmnt, -1)) == 1 &&
+		!limitPattern.MatchString(stmnt) &&
+		!selectModifierPattern.MatchString(stmnt)
+}
+
+// addToBatches finds the batch that a cell statement belongs to, creating it if necessary
+func addToBatches(batches map[string]*queryBatch, stmnt string, cellVars TemplateVariables) {
+	dataType := queryDataType(stmnt)
+	if dataType == "" || !batchable(stmnt) {
+		return
+	}
+	skeleton := stmnt
+	var clauses []batchClause
+	var values []string
+	for _, variable := range batchVariables {
+		value := cellVars[variable]
+		if value == "" {
+			continue
+		}
+		start, end, column, quoted, ok := findBatchClause(skeleton, value)
+		if !ok {
+			continue
+		}
+		skeleton = skeleton[:start] + batchMarker(len(clauses)) + skeleton[end:]
+		clauses = append(clauses, batchClause{variable: variable, column: column, quoted: quoted})
+		values = append(values, value)
+	}
+	if len(clauses) == 0 {
+		return
+	}
+	key := fmt.Sprintf("%s:%v:%s", dataType, clauses, skeleton)
+	batch, ok := batches[key]
+	if !ok {
+		batch = &queryBatch{skeleton: skeleton, dataType: dataType, clauses: clauses}
+		batches[key] = batch
+	}
+	batch.members = append(batch.members, batchMember{stmnt: stmnt, values: values})
+}
+
+// normalizeBatchValue lets "6" match 6 and "0.01" match 0.0100 when the key comes back from the database
+func normalizeBatchValue(value string) string {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return value
+}
+
+func batchValuesKey(values []string) string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = normalizeBatchValue(v)
+	}
+	return strings.Join(normalized, "\x00")
+}
+
+// statement builds the grouped statement of a batch
+func (batch *queryBatch) statement() string {
+	stmnt := batch.skeleton
+	keyColumns := make([]string, len(batch.clauses))
+	keyAliases := make([]string, len(batch.clauses))
+	for i, clause := range batch.clauses {
+		var values []string
+		seen := map[string]bool{}
+		for _, member := range batch.members {
+			if v := member.values[i]; !seen[v] {
+				seen[v] = true
+				if clause.quoted {
+					v = "'" + v + "'"
+				}
+				values = append(values, v)
+			}
+		}
+		sort.Strings(values)
+		stmnt = strings.Replace(stmnt, batchMarker(i), fmt.Sprintf("%s IN (%s)", clause.column, strings.Join(values, ",")), 1)
+		keyAliases[i] = fmt.Sprintf("batch_key_%d", i)
+		keyColumns[i] = fmt.Sprintf("%s AS %s", clause.column, keyAliases[i])
+	}
+	loc := selectPattern.FindStringIndex(stmnt)
+	stmnt = stmnt[:loc[1]] + " " + strings.Join(keyColumns, ", ") + "," + stmnt[loc[1]:]
+	loc = groupByPattern.FindStringIndex(stmnt)
+	return stmnt[:loc[1]] + " " + strings.Join(keyAliases, ", ") + "," + stmnt[loc[1]:]
+}
+
+func ctcFields(r *builder.CTCRecord) []any {
+	return []any{&r.Avtime, &r.Hit, &r.Miss, &r.Fa, &r.Cn}
+}
+
+func scalarFields(r *builder.ScalarRecord) []any {
+	return []any{&r.Avtime, &r.SquareDiffSum, &r.NSum, &r.ObsModelDiffSum, &r.ModelSum, &r.ObsSum, &r.AbsSum}
+}
+
+func preCalcFields(r *builder.PreCalcRecord) []any {
+	return []any{&r.Avtime, &r.Stat}
+}
+
+// runBatch runs the grouped statement and puts each member's records into the query cache.
+// Transient failures are retried with the director's retry policy, like the cell queries.
+func runBatch[R any, S ~[]R](ctx context.Context, director *Director, batch *queryBatch, fields func(*R) []any) error {
+	stmnt := batch.statement()
+	split, err := retry.Value(ctx, director.retryPolicy, "mysql_batch_query", isTransientQueryError, func(ctx context.Context) (map[string]S, error) {
+		return queryBatchRows[R, S](ctx, director, stmnt, len(batch.clauses), fields)
+	})
+	if err != nil {
+		return err
+	}
+	// one round trip instead of one per member
+	director.queryCache.countMiss()
+	batchedQueries.Inc()
+	for _, member := range batch.members {
+		// a cell without rows gets the same (nil) result as its own query would have returned
+		director.queryCache.prefill(cacheKey[S](member.stmnt), split[batchValuesKey(member.values)])
+	}
+	return nil
+}
+
+// queryBatchRows runs a grouped statement with numKeys key columns and splits its records by their key values
+func queryBatchRows[R any, S ~[]R](ctx context.Context, director *Director, stmnt string, numKeys int, fields func(*R) []any) (map[string]S, error) {
+	ctx, cancel := director.queryContext(ctx)
+	defer cancel()
+	rows, err := director.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, newQueryError(stmnt, fmt.Errorf("mysql_director runBatch Query failed: %w", err))
+	}
+	defer rows.Close()
+	split := map[string]S{}
+	keys := make([]sql.NullString, numKeys)
+	for rows.Next() {
+		var record R
+		dest := make([]any, 0, len(keys)+8)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest, fields(&record)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, newQueryError(stmnt, fmt.Errorf("mysql_director runBatch error reading row %w", err))
+		}
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = k.String
+		}
+		key := batchValuesKey(values)
+		split[key] = append(split[key], record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(stmnt, fmt.Errorf("mysql_director runBatch error reading rows %w", err))
+	}
+	return split, nil
+}
+
+// prefetchBatches runs the grouped statements for all the leaves of a queryMap region
+func (director *Director) prefetchBatches(ctx context.Context, queryRegionName string, queryRegion scorecard.QueryRegion) {
+	batches := map[string]*queryBatch{}
+	director.collectBatches(batches, queryRegionName, queryRegion)
+	keys := make([]string, 0, len(batches))
+	for k, batch := range batches {
+		if len(batch.members) > 1 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ctx.Err() != nil {
+			return
+		}
+		batch := batches[k]
+		var err error
+		switch batch.dataType {
+		case "CTC":
+			err = runBatch[builder.CTCRecord, builder.CTCRecords](ctx, director, batch, ctcFields)
+		case "Scalar":
+			err = runBatch[builder.ScalarRecord, builder.ScalarRecords](ctx, director, batch, scalarFields)
+		case "PreCalc":
+			err = runBatch[builder.PreCalcRecord, builder.PreCalcRecords](ctx, director, batch, preCalcFields)
+		}
+		if err != nil {
+			// the cells will run their own queries
+			log.Printf("mysql_director batch of %d cells failed, falling back to cell queries: %v", len(batch.members), err)
+		}
+	}
+}
+
+// dataTypeCacheKey is the cache key that cachedQuery will use for a statement of the given data type
+func dataTypeCacheKey(dataType, stmnt string) string {
+	switch dataType {
+	case "CTC":
+		return cacheKey[builder.CTCRecords](stmnt)
+	case "Scalar":
+		return cacheKey[builder.ScalarRecords](stmnt)
+	default:
+		return cacheKey[builder.PreCalcRecords](stmnt)
+	}
+}
+
+func (director *Director) collectBatches(batches map[string]*queryBatch, queryRegionName string, queryRegion scorecard.QueryRegion) {
+	for _, k := range queryRegion.Keys() {
+		leaf, _ := queryRegion.Get(k)
+		keychain := k.Keychain(queryRegionName)
+		ctlStmnts, expStmnts, err := director.renderCellQueries(&cell{keychain: keychain, queryLeaf: leaf})
+		if err != nil {
+			continue
+		}
+		cellVars := cellTemplateVariables(keychain)
+		// each chunk of the date range is batched separately, the chunks have different skeletons
+		for _, stmnt := range append(ctlStmnts, expStmnts...) {
+			// another region (or block) may already have fetched this one
+			if _, cached := director.queryCache.lookup(dataTypeCacheKey(queryDataType(stmnt), stmnt)); !cached {
+				addToBatches(batches, stmnt, cellVars)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package director
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+	"github.com/go-sql-driver/mysql"
+)
+
+const batchTestTemplate = "select ceil(3600*floor((m0.time+1800)/3600)) as avtime, sum(m0.yy) as hit, sum(m0.ny) as miss, sum(m0.yn) as fa, sum(m0.nn) as cn " +
+	"from ceiling_sums2.%s as m0 where 1 = 1 and m0.time >= {{fromSecs}} and m0.time <= {{toSecs}} and m0.trsh = 50 and m0.fcst_len = %s " +
+	"group by avtime order by avtime;"
+
+func batchTestStatement(model, fcstLen string) string {
+	return strings.Replace(strings.Replace(batchTestTemplate, "%s", model, 1), "%s", fcstLen, 1)
+}
+
+func batchTestLeaf(fcstLen string) scorecard.QueryLeaf {
+	return scorecard.QueryLeaf{
+		ControlQueryTemplate:      batchTestStatement("HRRR_OPS", fcstLen),
+		ExperimentalQueryTemplate: batchTestStatement("RRFS_A", fcstLen),
+	}
+}
+
+func Test_findBatchClause(t *testing.T) {
+	tests := []struct {
+		name       string
+		stmnt      string
+		value      string
+		wantColumn string
+		wantQuoted bool
+		wantOk     bool
+	}{
+		{name: "number", stmnt: "where m0.fcst_len = 6 group by", value: "6", wantColumn: "m0.fcst_len", wantOk: true},
+		{name: "quoted", stmnt: "where fcst_len='12' group by", value: "12", wantColumn: "fcst_len", wantQuoted: true, wantOk: true},
+		{name: "prefix of a longer value", stmnt: "where m0.fcst_len = 12", value: "1", wantOk: false},
+		{name: "prefix of a decimal", stmnt: "where m0.trsh = 0.015", value: "0.01", wantOk: false},
+		{name: "ambiguous", stmnt: "where m0.fcst_len = 3 and m0.trsh = 3", value: "3", wantOk: false},
+		{name: "not a column", stmnt: "where 1 = 1", value: "1", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, column, quoted, ok := findBatchClause(tt.stmnt, tt.value)
+			if ok != tt.wantOk || (ok && (column != tt.wantColumn || quoted != tt.wantQuoted)) {
+				t.Errorf("findBatchClause() = %q %v %v, want %q %v %v", column, quoted, ok, tt.wantColumn, tt.wantQuoted, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_batchable(t *testing.T) {
+	tests := []struct {
+		name  string
+		stmnt string
+		want  bool
+	}{
+		{name: "grouped", stmnt: "SELECT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: true},
+		{name: "selected column named like a modifier", stmnt: "select distinct_count as n, stat from t where fcst_len = 6 group by n", want: true},
+		{name: "no group by", stmnt: "SELECT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6", want: false},
+		{name: "subquery", stmnt: "SELECT avtime, stat FROM (SELECT m0.time AS avtime, m0.stat AS stat FROM t AS m0) AS s WHERE fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "limit", stmnt: "SELECT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime LIMIT 10", want: false},
+		{name: "distinct", stmnt: "SELECT DISTINCT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "distinctrow", stmnt: "select distinctrow m0.time as avtime, m0.stat as stat from t as m0 where m0.fcst_len = 6 group by avtime", want: false},
+		{name: "all", stmnt: "SELECT ALL m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "sql_no_cache", stmnt: "SELECT SQL_NO_CACHE m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "sql_big_result", stmnt: "SELECT\n\tSQL_BIG_RESULT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "high_priority", stmnt: "SELECT HIGH_PRIORITY m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "straight_join", stmnt: "SELECT STRAIGHT_JOIN m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+		{name: "optimizer hint", stmnt: "SELECT /*+ MAX_EXECUTION_TIME(1000) */ m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = 6 GROUP BY avtime", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchable(tt.stmnt); got != tt.want {
+				t.Errorf("batchable() = %v, want %v", got, tt.want)
+			}
+			batches := map[string]*queryBatch{}
+			addToBatches(batches, tt.stmnt, TemplateVariables{TemplateForecastLength: "6"})
+			if got := len(batches) == 1; got != tt.want {
+				t.Errorf("addToBatches() made %d batches, want a batch %v", len(batches), tt.want)
+			}
+		})
+	}
+}
+
+func Test_queryBatchStatement(t *testing.T) {
+	batches := map[string]*queryBatch{}
+	for _, fcst := range []string{"0", "6", "3"} {
+		stmnt := "SELECT m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len = " + fcst + " GROUP BY avtime ORDER BY avtime"
+		addToBatches(batches, stmnt, TemplateVariables{TemplateForecastLength: fcst})
+	}
+	if len(batches) != 1 {
+		t.Fatalf("addToBatches() made %d batches, want 1", len(batches))
+	}
+	for _, batch := range batches {
+		want := "SELECT m0.fcst_len AS batch_key_0, m0.time AS avtime, m0.stat AS stat FROM t AS m0 WHERE m0.fcst_len IN (0,3,6) GROUP BY batch_key_0, avtime ORDER BY avtime"
+		if got := batch.statement(); got != want {
+			t.Errorf("statement() = %q, want %q", got, want)
+		}
+	}
+}
+
+func Test_prefetchBatches(t *testing.T) {
+	db, fds := newFakeDB(t, func(stmnt string) fakeResult {
+		if !strings.Contains(stmnt, "batch_key_0") {
+			t.Errorf("prefetchBatches() ran an unbatched statement %q", stmnt)
+			return fakeResult{err: driver.ErrBadConn}
+		}
+		if !strings.Contains(stmnt, "m0.fcst_len IN (0,3,6)") {
+			t.Errorf("prefetchBatches() statement is missing the IN clause %q", stmnt)
+		}
+		return fakeResult{
+			columns: []string{"batch_key_0", "avtime", "hit", "miss", "fa", "cn"},
+			rows: [][]driver.Value{
+				{"0", int64(3600), 1.0, 2.0, 3.0, 4.0},
+				{"0", int64(7200), 1.0, 2.0, 3.0, 4.0},
+				{"3", int64(3600), 5.0, 6.0, 7.0, 8.0},
+			},
+		}
+	})
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 10000}, queryCache: NewQueryCache()}
+	queryRegion := scorecard.QueryRegion{
+		"CSI (Critical Success Index)": {
+			"Ceiling": {
+				"500 (ceiling <500 ft)": {
+					"level_NA": {
+						"0": batchTestLeaf("0"),
+						"3": batchTestLeaf("3"),
+						"6": batchTestLeaf("6"),
+					},
+				},
+			},
+		},
+	}
+	keychain := []string{"All HRRR domain"}
+	director.prefetchBatches(context.Background(), keychain[0], queryRegion)
+	if got := len(fds.Queries()); got != 2 {
+		t.Fatalf("prefetchBatches() ran %d statements, want one for control and one for experimental", got)
+	}
+
+	// the cells should now be answered from the cache
+	wantLen := map[string]int{"0": 2, "3": 1, "6": 0}
+	for fcst, want := range wantLen {
+		leafKeychain := append(keychain, "CSI (Critical Success Index)", "Ceiling", "500 (ceiling <500 ft)", "level_NA", fcst)
+		ctlQuery, expQuery, err := director.renderLeafQueries(leafKeychain, batchTestLeaf(fcst), director.dateRange)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmnt := range []string{ctlQuery, expQuery} {
+			records, err := cachedQuery(context.Background(), director, stmnt, director.queryDataCTC)
+			if err != nil {
+				t.Fatalf("cachedQuery() error %v", err)
+			}
+			if len(records) != want {
+				t.Errorf("forecast length %s got %d records, want %d", fcst, len(records), want)
+			}
+		}
+	}
+	stmnt := strings.NewReplacer("{{fromSecs}}", "0", "{{toSecs}}", "10000").Replace(batchTestStatement("HRRR_OPS", "3"))
+	if fcst3, _ := cachedQuery(context.Background(), director, stmnt, director.queryDataCTC); len(fcst3) != 1 || fcst3[0] != (builder.CTCRecord{Avtime: 3600, Hit: 5, Miss: 6, Fa: 7, Cn: 8}) {
+		t.Errorf("forecast length 3 control records = %v", fcst3)
+	}
+	if got := len(fds.Queries()); got != 2 {
+		t.Errorf("cells ran %d more statements after the batch", got-2)
+	}
+	hits, misses := director.queryCache.Stats()
+	if hits != 7 || misses != 2 {
+		t.Errorf("query cache hits %d misses %d, want 7 and 2", hits, misses)
+	}
+}
+
+func Test_prefetchBatchesRetried(t *testing.T) {
+	var attempts atomic.Int32
+	db, fds := newFakeDB(t, func(stmnt string) fakeResult {
+		if attempts.Add(1) <= 2 {
+			// the server drops the connection twice, then the batch succeeds
+			return fakeResult{err: &mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"}}
+		}
+		return fakeResult{
+			columns: []string{"batch_key_0", "avtime", "hit", "miss", "fa", "cn"},
+			rows:    [][]driver.Value{{"3", int64(3600), 5.0, 6.0, 7.0, 8.0}},
+		}
+	})
+	director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 10000}, queryCache: NewQueryCache()}
+	director.SetRetryPolicy(retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	queryRegion := scorecard.QueryRegion{
+		"CSI (Critical Success Index)": {
+			"Ceiling": {
+				"500 (ceiling <500 ft)": {
+					"level_NA": {
+						"0": batchTestLeaf("0"),
+						"3": batchTestLeaf("3"),
+					},
+				},
+			},
+		},
+	}
+	director.prefetchBatches(context.Background(), "All HRRR domain", queryRegion)
+	if got := len(fds.Queries()); got != 4 {
+		t.Errorf("prefetchBatches() ran %d statements, want the control batch three times and the experimental batch once", got)
+	}
+	stmnt := strings.NewReplacer("{{fromSecs}}", "0", "{{toSecs}}", "10000").Replace(batchTestStatement("HRRR_OPS", "3"))
+	if fcst3, _ := cachedQuery(context.Background(), director, stmnt, director.queryDataCTC); len(fcst3) != 1 {
+		t.Errorf("forecast length 3 control records = %v, want the records of the retried batch", fcst3)
+	}
+	if got := len(fds.Queries()); got != 4 {
+		t.Errorf("cells ran %d more statements after the batch", got-4)
+	}
+}
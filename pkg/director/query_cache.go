@@ -0,0 +1,181 @@
+package director
+
+/*
+A QueryCache holds the results of the queries that have been run during a single scorecard run.
+Many cells share the same control (or experimental) query, e.g. the HRRR control for a region,
+statistic family, and forecast length, so the manager creates one QueryCache per run and gives it
+to all of its directors.
+
+Queries are keyed by their normalized rendered SQL (and the record type they are scanned into).
+Concurrent requests for the same query are collapsed with single-flight semantics - the first
+request runs the query and the others wait for its result. Errors are not cached so a failed
+query will be tried again by the next cell that needs it. The cache holds at most a number of
+results, the least recently used result is dropped to make room for a new one and a cell that
+needs it again queries it again. The query runs with the context of the
+first request, if that context is cancelled (e.g. its region failed) the waiting requests whose
+contexts are still live run the query again.
+*/
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultQueryCacheEntries is the number of query results that a QueryCache holds by default
+const DefaultQueryCacheEntries = 10000
+
+type QueryCache struct {
+	group      singleflight.Group
+	lock       sync.Mutex // for results and recent
+	results    map[string]*list.Element
+	recent     *list.List // of *cacheEntry, the most recently used first
+	maxEntries int
+	hits       atomic.Int64
+	misses     atomic.Int64
+	evictions  atomic.Int64
+}
+
+type cacheEntry struct {
+	key    string
+	result interface{}
+}
+
+// NewQueryCache returns an empty QueryCache of DefaultQueryCacheEntries that is safe for concurrent use
+func NewQueryCache() *QueryCache {
+	return NewQueryCacheSize(DefaultQueryCacheEntries)
+}
+
+// NewQueryCacheSize returns an empty QueryCache that holds at most maxEntries results, less than 1 means no limit
+func NewQueryCacheSize(maxEntries int) *QueryCache {
+	return &QueryCache{results: make(map[string]*list.Element), recent: list.New(), maxEntries: maxEntries}
+}
+
+// Stats returns the number of cache hits and misses so far.
+// A request that joins a query that is already in flight counts as a hit.
+func (qc *QueryCache) Stats() (hits, misses int64) {
+	return qc.hits.Load(), qc.misses.Load()
+}
+
+// Evictions returns the number of results that were dropped to make room for others
+func (qc *QueryCache) Evictions() int64 {
+	return qc.evictions.Load()
+}
+
+// normalizeQuery collapses whitespace and removes trailing semicolons so that
+// statements that only differ in formatting share a cache entry
+func normalizeQuery(stmnt string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(stmnt), " "), "; ")
+}
+
+func (qc *QueryCache) lookup(key string) (interface{}, bool) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	element, ok := qc.results[key]
+	if !ok {
+		return nil, false
+	}
+	qc.recent.MoveToFront(element)
+	return element.Value.(*cacheEntry).result, true
+}
+
+func (qc *QueryCache) store(key string, result interface{}) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	if element, ok := qc.results[key]; ok {
+		element.Value.(*cacheEntry).result = result
+		qc.recent.MoveToFront(element)
+		return
+	}
+	qc.results[key] = qc.recent.PushFront(&cacheEntry{key: key, result: result})
+	for qc.maxEntries > 0 && qc.recent.Len() > qc.maxEntries {
+		oldest := qc.recent.Back()
+		qc.recent.Remove(oldest)
+		delete(qc.results, oldest.Value.(*cacheEntry).key)
+		qc.evictions.Add(1)
+	}
+}
+
+// prefill puts a result that was fetched some other way (e.g. by a batch) into the cache
+func (qc *QueryCache) prefill(key string, result interface{}) {
+	qc.store(key, result)
+}
+
+// countMiss records a database round trip that did not go through get
+func (qc *QueryCache) countMiss() {
+	qc.misses.Add(1)
+	queryCacheMisses.Inc()
+}
+
+// get returns the cached result for key or runs query to get it
+func (qc *QueryCache) get(key string, query func() (interface{}, error)) (interface{}, error) {
+	if result, ok := qc.lookup(key); ok {
+		qc.hits.Add(1)
+		queryCacheHits.Inc()
+		return result, nil
+	}
+	executed := false
+	result, err, _ := qc.group.Do(key, func() (interface{}, error) {
+		// another caller may have finished this query between the lookup and the Do
+		if result, ok := qc.lookup(key); ok {
+			return result, nil
+		}
+		executed = true
+		result, err := query()
+		if err != nil {
+			return nil, err
+		}
+		qc.store(key, result)
+		return result, nil
+	})
+	if executed {
+		qc.misses.Add(1)
+		queryCacheMisses.Inc()
+	} else {
+		qc.hits.Add(1)
+		queryCacheHits.Inc()
+	}
+	return result, err
+}
+
+// cacheKey is the key of a statement whose result is scanned into T. The record type is part
+// of the key because the same statement could be scanned differently.
+func cacheKey[T any](stmnt string) string {
+	var zero T
+	return fmt.Sprintf("%T:%s", zero, normalizeQuery(stmnt))
+}
+
+// cachedQuery runs query for stmnt through the director's query cache (if it has one).
+// Transient failures are retried with the director's retry policy before anything is cached.
+func cachedQuery[T any](ctx context.Context, director *Director, stmnt string, query func(context.Context, string) (T, error)) (T, error) {
+	retried := func() (T, error) {
+		return retry.Value(ctx, director.retryPolicy, "mysql_query", isTransientQueryError, func(ctx context.Context) (T, error) {
+			return query(ctx, stmnt)
+		})
+	}
+	if director.queryCache == nil {
+		return retried()
+	}
+	var zero T
+	for {
+		result, err := director.queryCache.get(cacheKey[T](stmnt), func() (interface{}, error) {
+			return retried()
+		})
+		// the caller that ran the query was cancelled, this caller wasn't
+		if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return zero, err
+		}
+		return result.(T), nil
+	}
+}
@@ -0,0 +1,167 @@
+package director
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+)
+
+func Test_normalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		stmnt string
+		want  string
+	}{
+		{name: "already normal", stmnt: "select 1", want: "select 1"},
+		{name: "whitespace", stmnt: "  select\n\tavtime,   hit\nfrom t  ", want: "select avtime, hit from t"},
+		{name: "trailing semicolon", stmnt: "select 1 ;\n", want: "select 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeQuery(tt.stmnt); got != tt.want {
+				t.Errorf("normalizeQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cachedQuery(t *testing.T) {
+	director := &Director{queryCache: NewQueryCache()}
+	var executions atomic.Int32
+	release := make(chan struct{})
+	query := func(ctx context.Context, stmnt string) (builder.CTCRecords, error) {
+		executions.Add(1)
+		<-release // hold the query in flight until all of the callers are waiting
+		return builder.CTCRecords{{Avtime: 1, Hit: 1}}, nil
+	}
+
+	// concurrent callers with the same statement share one query
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stmnt := "select avtime, hit from t"
+			if i%2 == 0 {
+				stmnt = "select avtime,  hit\nfrom t;"
+			}
+			got, err := cachedQuery(context.Background(), director, stmnt, query)
+			if err != nil || len(got) != 1 {
+				t.Errorf("cachedQuery() = %v, %v", got, err)
+			}
+		}(i)
+	}
+	for executions.Load() == 0 {
+		runtime.Gosched() // wait for the first caller to start the query
+	}
+	close(release)
+	wg.Wait()
+	if got := executions.Load(); got != 1 {
+		t.Errorf("cachedQuery() ran the query %d times, want 1", got)
+	}
+	hits, misses := director.queryCache.Stats()
+	if hits != 9 || misses != 1 {
+		t.Errorf("cachedQuery() hits %d misses %d, want 9 and 1", hits, misses)
+	}
+
+	// a different statement is a miss
+	_, _ = cachedQuery(context.Background(), director, "select avtime, hit from t2", query)
+	if _, misses = director.queryCache.Stats(); misses != 2 {
+		t.Errorf("cachedQuery() misses %d, want 2", misses)
+	}
+}
+
+func Test_cachedQueryErrorsAreNotCached(t *testing.T) {
+	director := &Director{queryCache: NewQueryCache()}
+	calls := 0
+	query := func(ctx context.Context, stmnt string) (builder.ScalarRecords, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("connection lost")
+		}
+		return builder.ScalarRecords{{Avtime: 1}}, nil
+	}
+	if _, err := cachedQuery(context.Background(), director, "select 1", query); err == nil {
+		t.Fatal("cachedQuery() expected an error")
+	}
+	got, err := cachedQuery(context.Background(), director, "select 1", query)
+	if err != nil || len(got) != 1 {
+		t.Errorf("cachedQuery() = %v, %v after a failed query", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("cachedQuery() ran the query %d times, want 2", calls)
+	}
+}
+
+func Test_cachedQueryCancelledLeader(t *testing.T) {
+	director := &Director{queryCache: NewQueryCache()}
+	var executions atomic.Int32
+	query := func(ctx context.Context, stmnt string) (builder.ScalarRecords, error) {
+		if executions.Add(1) == 1 {
+			<-ctx.Done() // the first caller runs the query until its region is cancelled
+			return nil, fmt.Errorf("mysql_director queryData Query failed: %w", ctx.Err())
+		}
+		return builder.ScalarRecords{{Avtime: 1}}, nil
+	}
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := cachedQuery(leaderCtx, director, "select 1", query)
+		leaderErr <- err
+	}()
+	for executions.Load() == 0 {
+		runtime.Gosched() // wait for the leader to start the query
+	}
+	waiter := make(chan builder.ScalarRecords)
+	go func() {
+		got, err := cachedQuery(context.Background(), director, "select 1", query)
+		if err != nil {
+			t.Errorf("cachedQuery() of the waiter error = %v", err)
+		}
+		waiter <- got
+	}()
+	time.Sleep(20 * time.Millisecond) // let the waiter join the query in flight
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cachedQuery() of the leader error = %v, want context.Canceled", err)
+	}
+	if got := <-waiter; len(got) != 1 {
+		t.Errorf("cachedQuery() of the waiter = %v, want the rows", got)
+	}
+	if got := executions.Load(); got != 2 {
+		t.Errorf("cachedQuery() ran the query %d times, want 2", got)
+	}
+}
+
+func TestQueryCache_evictsLeastRecentlyUsed(t *testing.T) {
+	director := &Director{queryCache: NewQueryCacheSize(2)}
+	executions := map[string]int{}
+	query := func(ctx context.Context, stmnt string) (builder.ScalarRecords, error) {
+		executions[stmnt]++
+		return builder.ScalarRecords{{Avtime: 1}}, nil
+	}
+	// "select 1" is used again after "select 2", so "select 2" is the one that makes room for "select 3"
+	for _, stmnt := range []string{"select 1", "select 2", "select 1", "select 3", "select 1", "select 2"} {
+		if _, err := cachedQuery(context.Background(), director, stmnt, query); err != nil {
+			t.Fatalf("cachedQuery(%q) error = %v", stmnt, err)
+		}
+	}
+	want := map[string]int{"select 1": 1, "select 2": 2, "select 3": 1}
+	if !reflect.DeepEqual(executions, want) {
+		t.Errorf("cachedQuery() ran the queries %v times, want %v", executions, want)
+	}
+	if got := director.queryCache.Evictions(); got != 2 {
+		t.Errorf("Evictions() = %d, want 2", got)
+	}
+	if got := director.queryCache.recent.Len(); got != 2 {
+		t.Errorf("the cache holds %d results, want 2", got)
+	}
+}
@@ -0,0 +1,158 @@
+package director
+
+/*
+Date range chunking.
+
+A query over a long date range (e.g. a one year scorecard) can run past the server's timeouts.
+With a query chunk the director renders the templates of a cell once for each consecutive part of
+the date range, runs the control and experimental statement of every part (each one through the
+query cache, the retry policy and the query timeout like any other statement) and concatenates the
+records in Avtime order, so the builder gets the same records as from a single query.
+
+The chunks are calendar months or a whole number of hours, and their boundaries are at whole hours
+(at 00Z for months and for a whole number of days) so that they don't split the hourly avtime bins
+of the templates. A template that rounds the time to the nearest bin can still put the rows of one
+bin on both sides of a boundary, so the records of an Avtime that two chunks return are combined
+into one record like the single query would have summed them.
+*/
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+)
+
+// QueryChunk is the longest date range of a single query. The zero QueryChunk doesn't split the date range.
+type QueryChunk struct {
+	Monthly bool          // calendar months, from 00Z on the first of a month
+	Size    time.Duration // a whole number of hours, the chunks start at the multiples of Size since the epoch
+}
+
+// ParseQueryChunk parses "monthly" or a duration of whole hours e.g. "24h" or "168h", empty is no chunks
+func ParseQueryChunk(s string) (QueryChunk, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "":
+		return QueryChunk{}, nil
+	case "monthly", "month":
+		return QueryChunk{Monthly: true}, nil
+	}
+	size, err := time.ParseDuration(s)
+	if err != nil || size <= 0 || size%time.Hour != 0 {
+		return QueryChunk{}, fmt.Errorf("mysql_director ParseQueryChunk error the query chunk must be \"monthly\" or a whole number of hours like \"24h\", got %q", s)
+	}
+	return QueryChunk{Size: size}, nil
+}
+
+// String returns the chunk the way ParseQueryChunk reads it
+func (c QueryChunk) String() string {
+	switch {
+	case c.Monthly:
+		return "monthly"
+	case c.Size > 0:
+		return c.Size.String()
+	default:
+		return ""
+	}
+}
+
+// IsZero reports whether the chunk doesn't split the date range
+func (c QueryChunk) IsZero() bool {
+	return !c.Monthly && c.Size <= 0
+}
+
+// next returns the first chunk boundary after secs
+func (c QueryChunk) next(secs int64) int64 {
+	if c.Monthly {
+		t := time.Unix(secs, 0).UTC()
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	size := int64(c.Size / time.Second)
+	start := secs - secs%size
+	if secs < 0 && secs%size != 0 {
+		start -= size
+	}
+	return start + size
+}
+
+// dateChunks splits a date range into consecutive ranges at the boundaries of chunk. They don't overlap because the
+// query templates include both ends of a range. A zero chunk, or a range that fits in one, isn't split.
+func dateChunks(dateRange DateRange, chunk QueryChunk) []DateRange {
+	if chunk.IsZero() {
+		return []DateRange{dateRange}
+	}
+	var chunks []DateRange
+	from := dateRange.FromSecs
+	for boundary := chunk.next(from); boundary <= dateRange.ToSecs; boundary = chunk.next(boundary) {
+		chunks = append(chunks, DateRange{FromSecs: from, ToSecs: boundary - 1})
+		from = boundary
+	}
+	return append(chunks, DateRange{FromSecs: from, ToSecs: dateRange.ToSecs})
+}
+
+// queryChunks runs the statements of the chunks of a cell and concatenates their records in Avtime order.
+// A record whose Avtime an earlier chunk returned too is combined with the record of the earlier chunk.
+func queryChunks[S ~[]R, R any](ctx context.Context, director *Director, stmnts []string, query func(context.Context, string) (S, error),
+	avtime func(R) int64, combine func(R, R) (R, error),
+) (S, error) {
+	if len(stmnts) == 1 {
+		return cachedQuery(ctx, director, stmnts[0], query)
+	}
+	var data S
+	earlier := map[int64]int{} // the index in data of the Avtimes of the earlier chunks
+	for _, stmnt := range stmnts {
+		chunkData, err := cachedQuery(ctx, director, stmnt, query)
+		if err != nil {
+			return nil, err
+		}
+		chunkStart := len(data)
+		for _, record := range chunkData {
+			// the rows of a bin on both sides of a chunk boundary come back from both chunks
+			if i, ok := earlier[avtime(record)]; ok {
+				if data[i], err = combine(data[i], record); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			data = append(data, record)
+		}
+		for i := chunkStart; i < len(data); i++ {
+			earlier[avtime(data[i])] = i
+		}
+	}
+	// the chunks are in order and so are their records, unless a template doesn't order by avtime
+	sort.SliceStable(data, func(i, j int) bool { return avtime(data[i]) < avtime(data[j]) })
+	return data, nil
+}
+
+// combineCTC adds the counts of the parts of a bin
+func combineCTC(a, b builder.CTCRecord) (builder.CTCRecord, error) {
+	return builder.CTCRecord{Avtime: a.Avtime, Hit: a.Hit + b.Hit, Miss: a.Miss + b.Miss, Fa: a.Fa + b.Fa, Cn: a.Cn + b.Cn}, nil
+}
+
+// combineScalar adds the sums of the parts of a bin
+func combineScalar(a, b builder.ScalarRecord) (builder.ScalarRecord, error) {
+	return builder.ScalarRecord{
+		Avtime:          a.Avtime,
+		SquareDiffSum:   a.SquareDiffSum + b.SquareDiffSum,
+		NSum:            a.NSum + b.NSum,
+		ObsModelDiffSum: a.ObsModelDiffSum + b.ObsModelDiffSum,
+		ModelSum:        a.ModelSum + b.ModelSum,
+		ObsSum:          a.ObsSum + b.ObsSum,
+		AbsSum:          a.AbsSum + b.AbsSum,
+	}, nil
+}
+
+// combinePreCalc refuses to combine the parts of a bin, a precalculated statistic isn't a sum
+func combinePreCalc(a, _ builder.PreCalcRecord) (builder.PreCalcRecord, error) {
+	return a, fmt.Errorf("mysql_director queryChunks error the precalculated statistic of avtime %d is in two chunks of the date range and can't be combined", a.Avtime)
+}
+
+// SetQueryChunk sets the longest date range of a single query, the zero QueryChunk means the whole date range of the scorecard
+func (director *Director) SetQueryChunk(chunk QueryChunk) {
+	director.queryChunk = chunk
+}
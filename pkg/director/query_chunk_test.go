@@ -0,0 +1,188 @@
+package director
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func TestParseQueryChunk(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    QueryChunk
+		wantErr bool
+	}{
+		{s: "", want: QueryChunk{}},
+		{s: "monthly", want: QueryChunk{Monthly: true}},
+		{s: " Month ", want: QueryChunk{Monthly: true}},
+		{s: "168h", want: QueryChunk{Size: 168 * time.Hour}},
+		{s: "720h0m0s", want: QueryChunk{Size: 720 * time.Hour}},
+		{s: "90m", wantErr: true},
+		{s: "0h", wantErr: true},
+		{s: "-24h", wantErr: true},
+		{s: "weekly", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseQueryChunk(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQueryChunk() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseQueryChunk() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dateChunks(t *testing.T) {
+	utc := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	tests := []struct {
+		name      string
+		dateRange DateRange
+		chunk     QueryChunk
+		want      []DateRange
+	}{
+		{name: "no chunk", dateRange: DateRange{FromSecs: 0, ToSecs: 86400}, want: []DateRange{{FromSecs: 0, ToSecs: 86400}}},
+		{name: "fits", dateRange: DateRange{FromSecs: 0, ToSecs: 3599}, chunk: QueryChunk{Size: time.Hour}, want: []DateRange{{FromSecs: 0, ToSecs: 3599}}},
+		{name: "even", dateRange: DateRange{FromSecs: 0, ToSecs: 10799}, chunk: QueryChunk{Size: time.Hour},
+			want: []DateRange{{FromSecs: 0, ToSecs: 3599}, {FromSecs: 3600, ToSecs: 7199}, {FromSecs: 7200, ToSecs: 10799}}},
+		{name: "aligned to the hours", dateRange: DateRange{FromSecs: 100, ToSecs: 8000}, chunk: QueryChunk{Size: time.Hour},
+			want: []DateRange{{FromSecs: 100, ToSecs: 3599}, {FromSecs: 3600, ToSecs: 7199}, {FromSecs: 7200, ToSecs: 8000}}},
+		{name: "aligned to the days", dateRange: DateRange{FromSecs: utc(2023, 2, 19) + 20*3600, ToSecs: utc(2023, 2, 21) + 20*3600}, chunk: QueryChunk{Size: 24 * time.Hour},
+			want: []DateRange{
+				{FromSecs: utc(2023, 2, 19) + 20*3600, ToSecs: utc(2023, 2, 20) - 1},
+				{FromSecs: utc(2023, 2, 20), ToSecs: utc(2023, 2, 21) - 1},
+				{FromSecs: utc(2023, 2, 21), ToSecs: utc(2023, 2, 21) + 20*3600},
+			}},
+		{name: "monthly", dateRange: DateRange{FromSecs: utc(2023, 1, 15), ToSecs: utc(2023, 3, 10)}, chunk: QueryChunk{Monthly: true},
+			want: []DateRange{
+				{FromSecs: utc(2023, 1, 15), ToSecs: utc(2023, 2, 1) - 1},
+				{FromSecs: utc(2023, 2, 1), ToSecs: utc(2023, 3, 1) - 1},
+				{FromSecs: utc(2023, 3, 1), ToSecs: utc(2023, 3, 10)},
+			}},
+		{name: "monthly across a year", dateRange: DateRange{FromSecs: utc(2022, 12, 1), ToSecs: utc(2023, 1, 1)}, chunk: QueryChunk{Monthly: true},
+			want: []DateRange{{FromSecs: utc(2022, 12, 1), ToSecs: utc(2023, 1, 1) - 1}, {FromSecs: utc(2023, 1, 1), ToSecs: utc(2023, 1, 1)}}},
+		{name: "within a month", dateRange: DateRange{FromSecs: utc(2023, 2, 1), ToSecs: utc(2023, 3, 1) - 1}, chunk: QueryChunk{Monthly: true},
+			want: []DateRange{{FromSecs: utc(2023, 2, 1), ToSecs: utc(2023, 3, 1) - 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dateChunks(tt.dateRange, tt.chunk); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dateChunks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+var rangePattern = regexp.MustCompile(`>= (\d+) AND .* <= (\d+)`)
+
+// chunkTestRows returns the scalarTestRows of two days that are in the date range of the statement, latest first
+func chunkTestRows(stmnt string) fakeResult {
+	m := rangePattern.FindStringSubmatch(stmnt)
+	from, _ := strconv.ParseInt(m[1], 10, 64)
+	to, _ := strconv.ParseInt(m[2], 10, 64)
+	day := scalarTestRows(stmnt)
+	result := fakeResult{columns: day.columns}
+	for d := int64(1); d >= 0; d-- {
+		for i := len(day.rows) - 1; i >= 0; i-- {
+			row := append([]driver.Value(nil), day.rows[i]...)
+			row[0] = row[0].(int64) + d*86400
+			if row[0].(int64) >= from && row[0].(int64) <= to {
+				result.rows = append(result.rows, row)
+			}
+		}
+	}
+	return result
+}
+
+func TestDirector_RunChunked(t *testing.T) {
+	t.Setenv("PROC_DISABLE_QUERY_BATCHING", "")
+	run := func(chunk QueryChunk) (scorecard.ResultsRegion, []string) {
+		db, fds := newFakeDB(t, chunkTestRows)
+		region, queryRegion := scalarTestRegion("0", "6")
+		director := &Director{db: db, dateRange: DateRange{FromSecs: 0, ToSecs: 2*86400 - 1}, minorThreshold: 95, majorThreshold: 99,
+			queryCache: NewQueryCache(), queryChunk: chunk}
+		var summary RunSummary
+		if err := director.Run(context.Background(), "All HRRR domain", region, queryRegion, &summary); err != nil {
+			t.Fatalf("Run() error %v", err)
+		}
+		return region, fds.Queries()
+	}
+	whole, wholeQueries := run(QueryChunk{})
+	chunked, chunkedQueries := run(QueryChunk{Size: 12 * time.Hour})
+	if !reflect.DeepEqual(whole, chunked) {
+		t.Errorf("Run() with chunks = %v, want %v", chunked, whole)
+	}
+	// 2 cells with a control and an experimental query for each of the 4 chunks
+	if len(wholeQueries) != 4 || len(chunkedQueries) != 16 {
+		t.Errorf("Run() ran %d queries and %d with chunks, want 4 and 16", len(wholeQueries), len(chunkedQueries))
+	}
+	if !strings.Contains(chunkedQueries[0], ">= 0 AND") || !strings.Contains(chunkedQueries[0], "<= 43199 AND") {
+		t.Errorf("Run() first chunk query %q", chunkedQueries[0])
+	}
+}
+
+func Test_queryChunks(t *testing.T) {
+	db, _ := newFakeDB(t, chunkTestRows)
+	director := &Director{db: db}
+	stmnts := []string{"SELECT square_diff_sum WHERE avtime >= 0 AND avtime <= 86399", "SELECT square_diff_sum WHERE avtime >= 86400 AND avtime <= 172799"}
+	data, err := queryChunks(context.Background(), director, stmnts, director.queryDataScalar, scalarAvtime, combineScalar)
+	if err != nil {
+		t.Fatalf("queryChunks() error %v", err)
+	}
+	if len(data) != 48 {
+		t.Fatalf("queryChunks() returned %d records, want 48", len(data))
+	}
+	for i, record := range data {
+		if record.Avtime != int64(3600*i) {
+			t.Fatalf("queryChunks() record %d has avtime %d, want %d", i, record.Avtime, 3600*i)
+		}
+	}
+}
+
+func Test_queryChunksBinAcrossBoundary(t *testing.T) {
+	// the rows of the 3600 bin, from 1800 to 5399, are on both sides of the boundary at 3600
+	db, _ := newFakeDB(t, func(stmnt string) fakeResult {
+		columns := []string{"avtime", "hit", "miss", "fa", "cn"}
+		if strings.Contains(stmnt, ">= 0 AND") {
+			return fakeResult{columns: columns, rows: [][]driver.Value{{int64(0), 1.0, 1.0, 1.0, 1.0}, {int64(3600), 2.0, 0.0, 1.0, 3.0}}}
+		}
+		return fakeResult{columns: columns, rows: [][]driver.Value{{int64(3600), 3.0, 1.0, 0.0, 4.0}, {int64(7200), 1.0, 1.0, 1.0, 1.0}}}
+	})
+	director := &Director{db: db}
+	stmnts := []string{"SELECT hit WHERE m0.time >= 0 AND m0.time <= 3599", "SELECT hit WHERE m0.time >= 3600 AND m0.time <= 7199"}
+	data, err := queryChunks(context.Background(), director, stmnts, director.queryDataCTC, ctcAvtime, combineCTC)
+	if err != nil {
+		t.Fatalf("queryChunks() error %v", err)
+	}
+	want := builder.CTCRecords{
+		{Avtime: 0, Hit: 1, Miss: 1, Fa: 1, Cn: 1},
+		{Avtime: 3600, Hit: 5, Miss: 1, Fa: 1, Cn: 7},
+		{Avtime: 7200, Hit: 1, Miss: 1, Fa: 1, Cn: 1},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("queryChunks() = %v, want %v", data, want)
+	}
+
+	// a precalculated statistic can't be combined
+	db, _ = newFakeDB(t, func(stmnt string) fakeResult {
+		return fakeResult{columns: []string{"avtime", "stat"}, rows: [][]driver.Value{{int64(3600), 0.5}}}
+	})
+	director = &Director{db: db}
+	stmnts = []string{"SELECT stat WHERE m0.time >= 0 AND m0.time <= 3599", "SELECT stat WHERE m0.time >= 3600 AND m0.time <= 7199"}
+	_, err = queryChunks(context.Background(), director, stmnts, director.queryDataPreCalc, preCalcAvtime, combinePreCalc)
+	if err == nil || !strings.Contains(err.Error(), "avtime 3600 is in two chunks") {
+		t.Errorf("queryChunks() error = %v, want a precalculated statistic error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package director
+
+/*
+Query templates are the controlQueryTemplate and experimentalQueryTemplate strings
+that MATS embeds in the queryMap section of a scorecard document. A template contains
+{{placeholder}} markers that the director substitutes before a query is sent to the database.
+
+Only a declared set of placeholders is allowed. A placeholder that is not declared, or that is
+declared but has no value for a particular cell (e.g. {{threshold}} in a cell whose threshold is
+threshold_NA), is an error that is reported with the path of the offending leaf, before any query
+is sent. This prevents templates from reaching MySQL with stray markers in them.
+
+The declared placeholders are
+	{{fromSecs}} {{toSecs}} - the scorecard date range in epoch seconds
+	{{region}} {{threshold}} {{level}} {{forecastLength}} - derived from the keys of the cell
+	{{validHours}} - comma separated list of the valid hours of the block (if the block has one)
+plus the scorecard variables, which are the scalar curve parameters of the block
+(e.g. "data-source" is available as {{dataSource}}).
+*/
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// the names of the declared placeholders that are not scorecard variables
+const (
+	TemplateFromSecs       = "fromSecs"
+	TemplateToSecs         = "toSecs"
+	TemplateRegion         = "region"
+	TemplateThreshold      = "threshold"
+	TemplateLevel          = "level"
+	TemplateForecastLength = "forecastLength"
+	TemplateValidHours     = "validHours"
+)
+
+// the keys of the cell, in order, below a region in the results and queryMap trees
+var cellKeychainVariables = []string{TemplateRegion, "", "", TemplateThreshold, TemplateLevel, TemplateForecastLength}
+
+// MATS cannot use dots in document keys so it encodes them
+const dotEncoding = "__DOT__"
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// TemplateVariables maps a declared placeholder name to its value.
+// A declared placeholder with an empty value is unfilled.
+type TemplateVariables map[string]string
+
+// TemplateError reports the placeholders in a query template that could not be rendered
+type TemplateError struct {
+	Path     string   // the leaf path, in the same format as builder GetPath
+	Template string   // controlQueryTemplate or experimentalQueryTemplate
+	Unknown  []string // placeholders that are not declared
+	Unfilled []string // placeholders that are declared but have no value for this leaf
+}
+
+func (e *TemplateError) Error() string {
+	var problems []string
+	if len(e.Unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown placeholders %v", e.Unknown))
+	}
+	if len(e.Unfilled) > 0 {
+		problems = append(problems, fmt.Sprintf("unfilled placeholders %v", e.Unfilled))
+	}
+	return fmt.Sprintf("query template %s at %q has %s", e.Template, e.Path, strings.Join(problems, " and "))
+}
+
+// ScorecardTemplateVariables converts the scalar parameters of a plotParams curve
+// into template variables, i.e. "control-data-source" becomes {{controlDataSource}}.
+// A curve parameter that is a list of hours named "valid-time" becomes {{validHours}}.
+func ScorecardTemplateVariables(curve map[string]interface{}) TemplateVariables {
+	vars := TemplateVariables{}
+	for key, value := range curve {
+		switch v := value.(type) {
+		case string:
+			if key == "valid-time" {
+				// MATS sets valid-time to "unused" when there are no valid hours
+				if v != "unused" {
+					vars[TemplateValidHours] = v
+				}
+				continue
+			}
+			vars[kebabToCamel(key)] = v
+		case []interface{}:
+			if key != "valid-time" {
+				continue
+			}
+			hours := make([]string, 0, len(v))
+			for _, h := range v {
+				hours = append(hours, fmt.Sprint(h))
+			}
+			vars[TemplateValidHours] = strings.Join(hours, ",")
+		}
+	}
+	return vars
+}
+
+func kebabToCamel(s string) string {
+	parts := strings.Split(s, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// cellTemplateVariables derives the cell variables from the keychain of a leaf
+// i.e. region -> statistic -> variable -> threshold -> level -> forecastLength
+func cellTemplateVariables(keychain []string) TemplateVariables {
+	vars := TemplateVariables{}
+	for i, name := range cellKeychainVariables {
+		if name == "" || i >= len(keychain) {
+			continue
+		}
+		vars[name] = cellKeyValue(keychain[i])
+	}
+	return vars
+}
+
+// cellKeyValue converts a cell key into a template value. Keys like
+// "0__DOT__01 (precip >= 0__DOT__01 in)" become "0.01" and "threshold_NA" is unfilled.
+func cellKeyValue(key string) string {
+	if strings.HasSuffix(key, "_NA") {
+		return ""
+	}
+	value := strings.ReplaceAll(key, dotEncoding, ".")
+	if i := strings.Index(value, " ("); i > 0 {
+		value = value[:i]
+	}
+	return value
+}
+
+// templateVariablesFor merges the director variables with the cell variables of a leaf
+func (director *Director) templateVariablesFor(keychain []string, dateRange DateRange) TemplateVariables {
+	vars := TemplateVariables{}
+	for k, v := range director.templateVariables {
+		vars[k] = v
+	}
+	for k, v := range cellTemplateVariables(keychain) {
+		vars[k] = v
+	}
+	// validHours is declared even if the block does not have any
+	if _, ok := vars[TemplateValidHours]; !ok {
+		vars[TemplateValidHours] = ""
+	}
+	vars[TemplateFromSecs] = fmt.Sprint(dateRange.FromSecs)
+	vars[TemplateToSecs] = fmt.Sprint(dateRange.ToSecs)
+	return vars
+}
+
+// renderTemplate substitutes all of the placeholders in a query template.
+// It returns a *TemplateError if any placeholder is unknown or unfilled.
+func renderTemplate(templateName, path, template string, vars TemplateVariables) (string, error) {
+	var unknown, unfilled []string
+	rendered := placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		value, declared := vars[name]
+		switch {
+		case !declared:
+			unknown = appendUnique(unknown, name)
+		case value == "":
+			unfilled = appendUnique(unfilled, name)
+		}
+		return value
+	})
+	if len(unknown) > 0 || len(unfilled) > 0 {
+		sort.Strings(unknown)
+		sort.Strings(unfilled)
+		return "", &TemplateError{Path: path, Template: templateName, Unknown: unknown, Unfilled: unfilled}
+	}
+	return rendered, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, l := range list {
+		if l == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// renderLeafQueries renders the control and experimental query templates of a queryMap leaf
+func (director *Director) renderLeafQueries(keychain []string, leaf scorecard.QueryLeaf, dateRange DateRange) (ctlQuery, expQuery string, err error) {
+	path := strings.Join(keychain, " -> ")
+	if leaf.ControlQueryTemplate == "" {
+		return "", "", fmt.Errorf("mysql_director leaf %q has no controlQueryTemplate", path)
+	}
+	if leaf.ExperimentalQueryTemplate == "" {
+		return "", "", fmt.Errorf("mysql_director leaf %q has no experimentalQueryTemplate", path)
+	}
+	vars := director.templateVariablesFor(keychain, dateRange)
+	ctlQuery, err = renderTemplate("controlQueryTemplate", path, leaf.ControlQueryTemplate, vars)
+	if err != nil {
+		return "", "", err
+	}
+	expQuery, err = renderTemplate("experimentalQueryTemplate", path, leaf.ExperimentalQueryTemplate, vars)
+	if err != nil {
+		return "", "", err
+	}
+	return ctlQuery, expQuery, nil
+}
+
+// validateQueryTemplates renders every leaf template of a queryMap region without running any queries
+func (director *Director) validateQueryTemplates(queryRegionName string, queryRegion scorecard.QueryRegion) error {
+	for _, k := range queryRegion.Keys() {
+		leaf, _ := queryRegion.Get(k)
+		if _, _, err := director.renderLeafQueries(k.Keychain(queryRegionName), leaf, director.dateRange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package director
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func Test_renderTemplate(t *testing.T) {
+	vars := TemplateVariables{
+		TemplateFromSecs:       "1675281600",
+		TemplateToSecs:         "1677700800",
+		TemplateForecastLength: "6",
+		TemplateThreshold:      "",
+	}
+	tests := []struct {
+		name         string
+		template     string
+		want         string
+		wantUnknown  []string
+		wantUnfilled []string
+	}{
+		{
+			name:     "date range",
+			template: "m0.time >= {{fromSecs}} and m0.time <= {{ toSecs }}",
+			want:     "m0.time >= 1675281600 and m0.time <= 1677700800",
+		},
+		{
+			name:     "no placeholders",
+			template: "select 1",
+			want:     "select 1",
+		},
+		{
+			name:        "unknown placeholder",
+			template:    "m0.fcst_len = {{fcstLen}} and m0.time >= {{fromSecs}}",
+			wantUnknown: []string{"fcstLen"},
+		},
+		{
+			name:         "unfilled placeholder",
+			template:     "m0.trsh = {{threshold}} and m0.fcst_len = {{forecastLength}}",
+			wantUnfilled: []string{"threshold"},
+		},
+		{
+			name:         "unknown and unfilled placeholders are all reported",
+			template:     "{{vxdata}} {{threshold}} {{threshold}} {{bogus}}",
+			wantUnknown:  []string{"bogus", "vxdata"},
+			wantUnfilled: []string{"threshold"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate("controlQueryTemplate", "All HRRR domain -> RMSE", tt.template, vars)
+			if tt.wantUnknown == nil && tt.wantUnfilled == nil {
+				if err != nil {
+					t.Fatalf("renderTemplate() unexpected error %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+				}
+				return
+			}
+			var templateErr *TemplateError
+			if !errors.As(err, &templateErr) {
+				t.Fatalf("renderTemplate() error = %v, want a *TemplateError", err)
+			}
+			if templateErr.Path != "All HRRR domain -> RMSE" {
+				t.Errorf("renderTemplate() error path = %q", templateErr.Path)
+			}
+			if !reflect.DeepEqual(templateErr.Unknown, tt.wantUnknown) {
+				t.Errorf("renderTemplate() unknown = %v, want %v", templateErr.Unknown, tt.wantUnknown)
+			}
+			if !reflect.DeepEqual(templateErr.Unfilled, tt.wantUnfilled) {
+				t.Errorf("renderTemplate() unfilled = %v, want %v", templateErr.Unfilled, tt.wantUnfilled)
+			}
+		})
+	}
+}
+
+func Test_cellTemplateVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		keychain []string
+		want     TemplateVariables
+	}{
+		{
+			name:     "scalar cell",
+			keychain: []string{"All HRRR domain", "RMSE", "2m RH", "threshold_NA", "level_NA", "6"},
+			want: TemplateVariables{
+				TemplateRegion:         "All HRRR domain",
+				TemplateThreshold:      "",
+				TemplateLevel:          "",
+				TemplateForecastLength: "6",
+			},
+		},
+		{
+			name:     "ctc cell with an encoded threshold",
+			keychain: []string{"Continental US", "CSI (Critical Success Index)", "24 Hour Precipitation", "0__DOT__01 (precip >= 0__DOT__01 in)", "level_NA", "0"},
+			want: TemplateVariables{
+				TemplateRegion:         "Continental US",
+				TemplateThreshold:      "0.01",
+				TemplateLevel:          "",
+				TemplateForecastLength: "0",
+			},
+		},
+		{
+			name:     "upper air cell",
+			keychain: []string{"East of 109W", "Bias (Model - Obs)", "RH", "threshold_NA", "300", "3"},
+			want: TemplateVariables{
+				TemplateRegion:         "East of 109W",
+				TemplateThreshold:      "",
+				TemplateLevel:          "300",
+				TemplateForecastLength: "3",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cellTemplateVariables(tt.keychain); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cellTemplateVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ScorecardTemplateVariables(t *testing.T) {
+	curve := map[string]interface{}{
+		"application":         "Surface",
+		"control-data-source": "HRRR_OPS",
+		"data-source":         "RRFS_A",
+		"valid-time":          []interface{}{"0", "12"},
+		"region":              []interface{}{"All HRRR domain"},
+	}
+	want := TemplateVariables{
+		"application":       "Surface",
+		"controlDataSource": "HRRR_OPS",
+		"dataSource":        "RRFS_A",
+		TemplateValidHours:  "0,12",
+	}
+	if got := ScorecardTemplateVariables(curve); !reflect.DeepEqual(got, want) {
+		t.Errorf("ScorecardTemplateVariables() = %v, want %v", got, want)
+	}
+	curve["valid-time"] = "unused"
+	delete(want, TemplateValidHours)
+	if got := ScorecardTemplateVariables(curve); !reflect.DeepEqual(got, want) {
+		t.Errorf("ScorecardTemplateVariables() with unused valid-time = %v, want %v", got, want)
+	}
+}
+
+func Test_validateQueryTemplates(t *testing.T) {
+	director := &Director{dateRange: DateRange{FromSecs: 1, ToSecs: 2}}
+	queryRegion := scorecard.QueryRegion{
+		"RMSE": {
+			"2m RH": {
+				"threshold_NA": {
+					"level_NA": {
+						"0": {
+							ControlQueryTemplate:      "select {{fromSecs}}",
+							ExperimentalQueryTemplate: "select {{toSecs}}",
+						},
+						"3": {
+							ControlQueryTemplate:      "select {{fromSecs}}",
+							ExperimentalQueryTemplate: "select {{fromSecs}} and {{threshold}}",
+						},
+					},
+				},
+			},
+		},
+	}
+	err := director.validateQueryTemplates("All HRRR domain", queryRegion)
+	var templateErr *TemplateError
+	if !errors.As(err, &templateErr) {
+		t.Fatalf("validateQueryTemplates() error = %v, want a *TemplateError", err)
+	}
+	wantPath := "All HRRR domain -> RMSE -> 2m RH -> threshold_NA -> level_NA -> 3"
+	if templateErr.Path != wantPath || templateErr.Template != "experimentalQueryTemplate" {
+		t.Errorf("validateQueryTemplates() error at %q %q, want %q experimentalQueryTemplate", templateErr.Path, templateErr.Template, wantPath)
+	}
+}
@@ -0,0 +1,156 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// ResumeMode selects the cells of a document that a run processes
+type ResumeMode string
+
+const (
+	// ResumeOff processes every cell
+	ResumeOff ResumeMode = ""
+	// ResumeUnfilled processes the cells that have the error value, because they weren't processed
+	// yet (the run died) or because they failed
+	ResumeUnfilled ResumeMode = "unfilled"
+	// ResumeErrors only processes the cells that the checkpoint of the document records as failed,
+	// e.g. to recompute the cells that failed during a MySQL outage
+	ResumeErrors ResumeMode = "errors"
+)
+
+// ParseResumeMode returns the ResumeMode with the name, "" or "off" is ResumeOff
+func ParseResumeMode(name string) (ResumeMode, error) {
+	switch mode := ResumeMode(name); mode {
+	case ResumeOff, ResumeUnfilled, ResumeErrors:
+		return mode, nil
+	case "off":
+		return ResumeOff, nil
+	default:
+		return ResumeOff, fmt.Errorf("manager the resume mode must be off, unfilled or errors, got %q", name)
+	}
+}
+
+// SetResumeMode selects the cells that Run processes, the cells that it doesn't process keep their values
+func (mngr *Manager) SetResumeMode(mode ResumeMode) {
+	mngr.resumeMode = mode
+}
+
+// Checkpoint is the checkpoint member of a scorecard document. A region is recorded when its results
+// have been written to the document, with the cells that failed, so that a run can be resumed.
+type Checkpoint struct {
+	Blocks map[string]map[string]RegionCheckpoint `json:"blocks"`
+}
+
+// RegionCheckpoint records a region whose results were written to the document
+type RegionCheckpoint struct {
+	CompletedAt int64 `json:"completed_at"` // epoch seconds
+	// the cells whose value is the error value, by their keys (see scorecard.CellKeys.String), and why
+	CellErrors map[string]director.ErrorClass `json:"cell_errors,omitempty"`
+}
+
+// checkpointer keeps the checkpoint of a run and writes it to the document as the regions are completed
+type checkpointer struct {
+	lock       sync.Mutex // the regions are completed concurrently
+	checkpoint Checkpoint
+}
+
+// loadCheckpoint reads the checkpoint of the document for a run that resumes it or that has a scope, the
+// checkpoint is written whole so a scoped run keeps the regions and cells outside of its scope. A run that
+// processes every cell of the document, or a document that doesn't have a checkpoint, starts with an empty
+// checkpoint.
+func (mngr *Manager) loadCheckpoint(ctx context.Context) (*checkpointer, error) {
+	cp := &checkpointer{}
+	if mngr.resumeMode != ResumeOff || !mngr.scope.isWhole() {
+		err := mngr.getSubDocument(ctx, "checkpoint", &cp.checkpoint)
+		if err != nil && !errors.Is(err, ErrPathNotFound) {
+			return nil, fmt.Errorf("manager loadCheckpoint error %w", err)
+		}
+	}
+	if cp.checkpoint.Blocks == nil {
+		cp.checkpoint.Blocks = map[string]map[string]RegionCheckpoint{}
+	}
+	return cp, nil
+}
+
+// cellsToProcess returns the cells of a region that a run with the mode processes, nil means all of them
+func (cp *checkpointer) cellsToProcess(mode ResumeMode, blockName, regionName string, region scorecard.ResultsRegion) []scorecard.CellKeys {
+	if mode == ResumeOff {
+		return nil
+	}
+	cp.lock.Lock()
+	cellErrors := cp.checkpoint.Blocks[blockName][regionName].CellErrors
+	cp.lock.Unlock()
+	cells := []scorecard.CellKeys{}
+	for _, k := range region.Keys() {
+		switch mode {
+		case ResumeUnfilled:
+			if value, _ := region.Get(k); isErrorValue(value) {
+				cells = append(cells, k)
+			}
+		case ResumeErrors:
+			if _, failed := cellErrors[k.String()]; failed {
+				cells = append(cells, k)
+			}
+		}
+	}
+	return cells
+}
+
+// isErrorValue reports whether a cell has the error value, i.e. it wasn't processed or it failed
+func isErrorValue(value scorecard.CellValue) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case float64:
+		return v == builder.ErrorValue
+	case int:
+		return v == builder.ErrorValue
+	case json.Number:
+		return v.String() == fmt.Sprint(builder.ErrorValue)
+	default:
+		return false
+	}
+}
+
+// completeRegion records a region whose results were written to the document and writes the checkpoint.
+// cells are the cells that were processed (nil is all of them) and cellErrors are the ones that failed.
+// The failures of the cells that weren't processed are kept.
+func (mngr *Manager) completeRegion(ctx context.Context, blockName, regionName string,
+	cells []scorecard.CellKeys, cellErrors map[string]director.ErrorClass,
+) error {
+	cp := mngr.checkpoint
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	region := RegionCheckpoint{CompletedAt: time.Now().Unix(), CellErrors: map[string]director.ErrorClass{}}
+	if cells != nil {
+		previous := cp.checkpoint.Blocks[blockName][regionName].CellErrors
+		for key, class := range previous {
+			region.CellErrors[key] = class
+		}
+		for _, k := range cells {
+			delete(region.CellErrors, k.String())
+		}
+	}
+	for key, class := range cellErrors {
+		region.CellErrors[key] = class
+	}
+	if cp.checkpoint.Blocks[blockName] == nil {
+		cp.checkpoint.Blocks[blockName] = map[string]RegionCheckpoint{}
+	}
+	cp.checkpoint.Blocks[blockName][regionName] = region
+	// the whole checkpoint is written while it is locked so that a later write has all the regions of an earlier one
+	err := mngr.upsertSubDocument(ctx, "checkpoint", cp.checkpoint)
+	if err != nil {
+		return fmt.Errorf("manager checkpoint error: %w", err)
+	}
+	return nil
+}
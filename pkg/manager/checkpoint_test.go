@@ -0,0 +1,184 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func TestParseResumeMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ResumeMode
+		wantErr bool
+	}{
+		{name: "", want: ResumeOff},
+		{name: "off", want: ResumeOff},
+		{name: "unfilled", want: ResumeUnfilled},
+		{name: "errors", want: ResumeErrors},
+		{name: "all", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResumeMode(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResumeMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseResumeMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isErrorValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value scorecard.CellValue
+		want  bool
+	}{
+		{name: "nil", value: nil, want: true},
+		{name: "float64", value: -9999.0, want: true},
+		{name: "int", value: -9999, want: true},
+		{name: "json.Number", value: json.Number("-9999"), want: true},
+		{name: "value", value: 2.0},
+		{name: "json.Number value", value: json.Number("-2")},
+		{name: "object", value: map[string]interface{}{"Value": -9999.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isErrorValue(tt.value); got != tt.want {
+				t.Errorf("isErrorValue(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_checkpoint(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Put("SC:doc", []byte(`{"status": "pending"}`)); err != nil {
+		t.Fatal(err)
+	}
+	cell := func(fcst string) scorecard.CellKeys {
+		return scorecard.CellKeys{Statistic: "RMSE", Variable: "2m temperature", Threshold: "threshold_NA", Level: "level_NA", ForecastLength: fcst}
+	}
+	region := scorecard.ResultsRegion{}
+	region.Set(cell("0"), 2.0)
+	region.Set(cell("3"), -9999.0)
+	region.Set(cell("6"), -9999.0)
+	region.Set(cell("9"), -9999.0)
+
+	// the first run processes every cell, 3 and 6 fail and 9 isn't reached
+	mngr := &Manager{documentID: "SC:doc", store: store}
+	cp, err := mngr.loadCheckpoint(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mngr.checkpoint = cp
+	if cells := cp.cellsToProcess(mngr.resumeMode, "Block0", "All HRRR domain", region); cells != nil {
+		t.Errorf("cellsToProcess() = %v, want all the cells", cells)
+	}
+	err = mngr.completeRegion(ctx, "Block0", "All HRRR domain", nil, map[string]director.ErrorClass{
+		cell("3").String(): director.ErrorClassConnectionLost,
+		cell("6").String(): director.ErrorClassNoData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a resumed run reads the checkpoint of the document
+	tests := []struct {
+		mode ResumeMode
+		want []scorecard.CellKeys
+	}{
+		{mode: ResumeUnfilled, want: []scorecard.CellKeys{cell("3"), cell("6"), cell("9")}},
+		{mode: ResumeErrors, want: []scorecard.CellKeys{cell("3"), cell("6")}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			resumed := &Manager{documentID: "SC:doc", store: store, resumeMode: tt.mode}
+			cp, err := resumed.loadCheckpoint(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cp.cellsToProcess(tt.mode, "Block0", "All HRRR domain", region); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cellsToProcess() = %v, want %v", got, tt.want)
+			}
+			if got := cp.cellsToProcess(tt.mode, "Block1", "All HRRR domain", scorecard.ResultsRegion{}); got == nil || len(got) != 0 {
+				t.Errorf("cellsToProcess() of an empty region = %v, want no cells", got)
+			}
+		})
+	}
+
+	// recomputing the errors fixes 3, 6 fails again and the failures of the other cells are kept
+	mngr.resumeMode = ResumeErrors
+	if mngr.checkpoint, err = mngr.loadCheckpoint(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = mngr.completeRegion(ctx, "Block0", "All HRRR domain", []scorecard.CellKeys{cell("3")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written Checkpoint
+	if err := store.GetSubDocument(ctx, "SC:doc", "checkpoint", &written); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]director.ErrorClass{cell("6").String(): director.ErrorClassNoData}
+	if got := written.Blocks["Block0"]["All HRRR domain"]; !reflect.DeepEqual(got.CellErrors, want) || got.CompletedAt == 0 {
+		t.Errorf("the checkpoint of the region is %+v, want the errors %v", got, want)
+	}
+}
+
+func TestManager_checkpointScope(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	err := store.Put("SC:doc", []byte(`{"status": "ready", "checkpoint": {"blocks": {"Block0": {
+		"All HRRR domain": {"completed_at": 1, "cell_errors": {"RMSE -> 2m temperature -> threshold_NA -> level_NA -> 3": "no_data"}},
+		"Eastern HRRR domain": {"completed_at": 2, "cell_errors": {
+			"RMSE -> 2m temperature -> threshold_NA -> level_NA -> 3": "connection_lost",
+			"RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6": "timeout"}}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := func(fcst string) scorecard.CellKeys {
+		return scorecard.CellKeys{Statistic: "RMSE", Variable: "2m temperature", Threshold: "threshold_NA", Level: "level_NA", ForecastLength: fcst}
+	}
+	var before Checkpoint
+	if err := store.GetSubDocument(ctx, "SC:doc", "checkpoint", &before); err != nil {
+		t.Fatal(err)
+	}
+	eastern := before.Blocks["Block0"]["Eastern HRRR domain"]
+	if len(eastern.CellErrors) != 2 || eastern.CellErrors[cell("6").String()] != director.ErrorClassTimeout {
+		t.Fatalf("the checkpoint of the document is %+v, want its cell keys to be the CellKeys strings", before)
+	}
+
+	// recomputing one cell of one region without resuming keeps the rest of the checkpoint
+	scope, err := ParseScope("Block0", "", "Eastern HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mngr := &Manager{documentID: "SC:doc", store: store, scope: scope}
+	if mngr.checkpoint, err = mngr.loadCheckpoint(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = mngr.completeRegion(ctx, "Block0", "Eastern HRRR domain", []scorecard.CellKeys{cell("3")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written Checkpoint
+	if err := store.GetSubDocument(ctx, "SC:doc", "checkpoint", &written); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := written.Blocks["Block0"]["All HRRR domain"], before.Blocks["Block0"]["All HRRR domain"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("the checkpoint of the region outside of the scope is %+v, want %+v", got, want)
+	}
+	want := map[string]director.ErrorClass{cell("6").String(): director.ErrorClassTimeout}
+	if got := written.Blocks["Block0"]["Eastern HRRR domain"]; !reflect.DeepEqual(got.CellErrors, want) || got.CompletedAt <= 2 {
+		t.Errorf("the checkpoint of the scoped region is %+v, want the errors %v", got, want)
+	}
+}
@@ -0,0 +1,203 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+)
+
+// Config holds the processing settings. They are optional environment variables,
+// unlike the credentials in loadEnvironment which are required.
+type Config struct {
+	// PROC_DIRECTOR_WORKERS - the number of cells each director processes concurrently
+	DirectorWorkers int
+	// PROC_MYSQL_MAX_OPEN_CONNS - the number of MySQL connections that all the directors of all the runs of the
+	// process share. It is read by the first run, see globalMySQLPool
+	MySQLMaxOpenConns int
+	// PROC_QUERY_TIMEOUT - the limit for each database query e.g. "5m", a query that runs longer leaves its cell empty,
+	// zero or not set means no limit
+	QueryTimeout time.Duration
+	// PROC_QUERY_CHUNK - the longest date range of a single query, "monthly" or a whole number of hours e.g. "168h",
+	// longer scorecards are queried in chunks, not set means each query covers the whole date range
+	QueryChunk director.QueryChunk
+	// PROC_QUERY_CACHE_ENTRIES - the number of query results that the directors of a run share, the least recently
+	// used result is dropped to make room for another
+	QueryCacheEntries int
+	// PROC_SCORECARD_TIMEOUT - the limit for processing a whole scorecard e.g. "1h", the run fails when it is reached,
+	// zero or not set means no limit
+	ScorecardTimeout time.Duration
+	// PROC_RETRY_MAX_ATTEMPTS, PROC_RETRY_INITIAL_BACKOFF, PROC_RETRY_MAX_BACKOFF - how mysql queries and couchbase
+	// sub-document operations are retried after transient failures, PROC_RETRY_MAX_ATTEMPTS=1 turns retries off
+	Retry retry.Policy
+	// PROC_EXPLAIN - off, warn or refuse, whether the queries are EXPLAINed before the scorecard is processed
+	// and whether expensive queries are only reported or fail the run
+	ExplainMode ExplainMode
+	// PROC_EXPLAIN_MAX_ROWS - the estimated rows of a table above which a query is expensive
+	ExplainMaxRows int
+	// PROC_PROGRESS_INTERVAL - how often the progress of a run is written to the document e.g. "10s"
+	ProgressInterval time.Duration
+	// PROC_MAX_DIRECTORS - the number of regions (directors) of a run that are processed concurrently
+	MaxDirectors int
+	// PROC_GLOBAL_MAX_DIRECTORS - the number of directors that run concurrently across all the runs of the process.
+	// It is read by the first run, see globalDirectorSlots
+	GlobalMaxDirectors int
+	// PROC_PARTIAL_SUCCESS - true means that a region that fails doesn't stop the others, the document is
+	// processed except for the failed regions and its status is "partial"
+	PartialSuccess bool
+	// PROC_EXCLUSIONS - date ranges separated by ";" whose valid times are left out of the statistics of every
+	// scorecard e.g. obs outages, in addition to the exclusions of the scorecard's plotParams
+	Exclusions []string
+}
+
+// ExplainMode is what the query cost pre-flight does with expensive queries
+type ExplainMode string
+
+const (
+	ExplainOff    ExplainMode = "off"
+	ExplainWarn   ExplainMode = "warn"
+	ExplainRefuse ExplainMode = "refuse"
+)
+
+const (
+	defaultProgressInterval   = 10 * time.Second
+	defaultMaxDirectors       = 4
+	defaultGlobalMaxDirectors = 16
+)
+
+// loadConfig retrieves the processing settings from the environment, using defaults for unset variables
+func loadConfig() (Config, error) {
+	config := Config{
+		DirectorWorkers:    director.DefaultWorkers,
+		MySQLMaxOpenConns:  director.DefaultMaxOpenConns,
+		QueryCacheEntries:  director.DefaultQueryCacheEntries,
+		Retry:              retry.DefaultPolicy(),
+		ExplainMode:        ExplainOff,
+		ExplainMaxRows:     director.DefaultExplainMaxRows,
+		ProgressInterval:   defaultProgressInterval,
+		MaxDirectors:       defaultMaxDirectors,
+		GlobalMaxDirectors: defaultGlobalMaxDirectors,
+	}
+	var err error
+	if config.DirectorWorkers, err = getEnvInt("PROC_DIRECTOR_WORKERS", config.DirectorWorkers); err != nil {
+		return config, err
+	}
+	if config.MySQLMaxOpenConns, err = getEnvInt("PROC_MYSQL_MAX_OPEN_CONNS", config.MySQLMaxOpenConns); err != nil {
+		return config, err
+	}
+	if config.QueryTimeout, err = getEnvLimit("PROC_QUERY_TIMEOUT", config.QueryTimeout); err != nil {
+		return config, err
+	}
+	if config.QueryChunk, err = director.ParseQueryChunk(os.Getenv("PROC_QUERY_CHUNK")); err != nil {
+		return config, fmt.Errorf("manager loadConfig PROC_QUERY_CHUNK error: %w", err)
+	}
+	if config.QueryCacheEntries, err = getEnvInt("PROC_QUERY_CACHE_ENTRIES", config.QueryCacheEntries); err != nil {
+		return config, err
+	}
+	if config.ScorecardTimeout, err = getEnvLimit("PROC_SCORECARD_TIMEOUT", config.ScorecardTimeout); err != nil {
+		return config, err
+	}
+	if config.Retry.MaxAttempts, err = getEnvInt("PROC_RETRY_MAX_ATTEMPTS", config.Retry.MaxAttempts); err != nil {
+		return config, err
+	}
+	if config.Retry.InitialBackoff, err = getEnvDuration("PROC_RETRY_INITIAL_BACKOFF", config.Retry.InitialBackoff); err != nil {
+		return config, err
+	}
+	if config.Retry.MaxBackoff, err = getEnvDuration("PROC_RETRY_MAX_BACKOFF", config.Retry.MaxBackoff); err != nil {
+		return config, err
+	}
+	switch mode := ExplainMode(os.Getenv("PROC_EXPLAIN")); mode {
+	case "":
+	case ExplainOff, ExplainWarn, ExplainRefuse:
+		config.ExplainMode = mode
+	default:
+		return config, fmt.Errorf("manager loadConfig PROC_EXPLAIN must be off, warn or refuse, got %q", mode)
+	}
+	if config.ExplainMaxRows, err = getEnvInt("PROC_EXPLAIN_MAX_ROWS", config.ExplainMaxRows); err != nil {
+		return config, err
+	}
+	if config.ProgressInterval, err = getEnvDuration("PROC_PROGRESS_INTERVAL", config.ProgressInterval); err != nil {
+		return config, err
+	}
+	if config.MaxDirectors, err = getEnvInt("PROC_MAX_DIRECTORS", config.MaxDirectors); err != nil {
+		return config, err
+	}
+	if config.GlobalMaxDirectors, err = getEnvInt("PROC_GLOBAL_MAX_DIRECTORS", config.GlobalMaxDirectors); err != nil {
+		return config, err
+	}
+	if config.PartialSuccess, err = getEnvBool("PROC_PARTIAL_SUCCESS", config.PartialSuccess); err != nil {
+		return config, err
+	}
+	config.Exclusions = splitExclusions(os.Getenv("PROC_EXCLUSIONS"))
+	if _, err = parseExclusions(config.Exclusions, time.Now()); err != nil {
+		return config, fmt.Errorf("manager loadConfig PROC_EXCLUSIONS error: %w", err)
+	}
+	// don't really care what SINGLETHREADEDMANAGER env var is set to, just if it is set.
+	// SINGLETHREADEDMANGER is its old misspelled name
+	for _, name := range []string{"SINGLETHREADEDMANAGER", "SINGLETHREADEDMANGER"} {
+		if _, singleThreadedManager := os.LookupEnv(name); singleThreadedManager {
+			config.MaxDirectors = 1
+		}
+	}
+	// don't really care what SINGLETHREADEDDIRECTOR env var is set to, just if it is set
+	if _, singleThreadedDirector := os.LookupEnv("SINGLETHREADEDDIRECTOR"); singleThreadedDirector {
+		config.DirectorWorkers = 1
+	}
+	return config, nil
+}
+
+// getEnvInt returns the positive integer value of an environment variable or def if it isn't set
+func getEnvInt(name string, def int) (int, error) {
+	value, set := os.LookupEnv(name)
+	if !set || value == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 1 {
+		return def, fmt.Errorf("manager loadConfig %s must be a positive integer, got %q", name, value)
+	}
+	return i, nil
+}
+
+// getEnvBool returns the boolean value (like "true" or "0") of an environment variable or def if it isn't set
+func getEnvBool(name string, def bool) (bool, error) {
+	value, set := os.LookupEnv(name)
+	if !set || value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("manager loadConfig %s must be true or false, got %q", name, value)
+	}
+	return b, nil
+}
+
+// getEnvDuration returns the positive duration value (like "90s" or "5m") of an environment variable or def if it isn't set
+func getEnvDuration(name string, def time.Duration) (time.Duration, error) {
+	value, set := os.LookupEnv(name)
+	if !set || value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def, fmt.Errorf("manager loadConfig %s must be a positive duration like \"90s\" or \"5m\", got %q", name, value)
+	}
+	return d, nil
+}
+
+// getEnvLimit returns the duration value of an environment variable that limits how long something may take,
+// or def if it isn't set. Zero means no limit.
+func getEnvLimit(name string, def time.Duration) (time.Duration, error) {
+	value, set := os.LookupEnv(name)
+	if !set || value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return def, fmt.Errorf("manager loadConfig %s must be a duration like \"90s\" or \"5m\", or 0 for no limit, got %q", name, value)
+	}
+	return d, nil
+}
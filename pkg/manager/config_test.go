@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_loadConfig_maxDirectors(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		want       int
+		wantGlobal int
+		wantErr    bool
+	}{
+		{name: "defaults", want: defaultMaxDirectors, wantGlobal: defaultGlobalMaxDirectors},
+		{name: "set", env: map[string]string{"PROC_MAX_DIRECTORS": "2", "PROC_GLOBAL_MAX_DIRECTORS": "8"}, want: 2, wantGlobal: 8},
+		{name: "single threaded", env: map[string]string{"PROC_MAX_DIRECTORS": "2", "SINGLETHREADEDMANAGER": ""}, want: 1, wantGlobal: defaultGlobalMaxDirectors},
+		{name: "misspelled single threaded", env: map[string]string{"SINGLETHREADEDMANGER": "true"}, want: 1, wantGlobal: defaultGlobalMaxDirectors},
+		{name: "not positive", env: map[string]string{"PROC_MAX_DIRECTORS": "0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"PROC_MAX_DIRECTORS", "PROC_GLOBAL_MAX_DIRECTORS", "SINGLETHREADEDMANAGER", "SINGLETHREADEDMANGER"} {
+				// t.Setenv restores the variable after the test, set or not
+				value, set := tt.env[name]
+				t.Setenv(name, value)
+				if !set {
+					_ = os.Unsetenv(name)
+				}
+			}
+			config, err := loadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.MaxDirectors != tt.want || config.GlobalMaxDirectors != tt.wantGlobal {
+				t.Errorf("loadConfig() MaxDirectors = %v, GlobalMaxDirectors = %v, want %v and %v",
+					config.MaxDirectors, config.GlobalMaxDirectors, tt.want, tt.wantGlobal)
+			}
+		})
+	}
+}
+
+func Test_getEnvBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "1", want: true},
+		{value: "false", want: false},
+		{value: "yes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("PROC_PARTIAL_SUCCESS", tt.value)
+			got, err := getEnvBool("PROC_PARTIAL_SUCCESS", false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getEnvBool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getEnvBool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadConfig_timeouts(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		scorecard     string
+		wantQuery     time.Duration
+		wantScorecard time.Duration
+		wantErr       bool
+	}{
+		{name: "no limits by default"},
+		{name: "set", query: "5m", scorecard: "1h", wantQuery: 5 * time.Minute, wantScorecard: time.Hour},
+		{name: "zero is no limit", query: "0", scorecard: "0s"},
+		{name: "negative", scorecard: "-1h", wantErr: true},
+		{name: "not a duration", query: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROC_QUERY_TIMEOUT", tt.query)
+			t.Setenv("PROC_SCORECARD_TIMEOUT", tt.scorecard)
+			config, err := loadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.QueryTimeout != tt.wantQuery || config.ScorecardTimeout != tt.wantScorecard {
+				t.Errorf("loadConfig() QueryTimeout = %v, ScorecardTimeout = %v, want %v and %v",
+					config.QueryTimeout, config.ScorecardTimeout, tt.wantQuery, tt.wantScorecard)
+			}
+		})
+	}
+}
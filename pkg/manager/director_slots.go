@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+var (
+	directorSlotsOnce sync.Once
+	directorSlots     *semaphore.Weighted
+)
+
+// globalDirectorSlots returns the semaphore that caps the directors of all the runs of the process. It is created
+// with size slots by the first run, the size of later runs is ignored. A semaphore.Weighted hands out its slots in
+// the order that they were asked for, and each run asks for at most PROC_MAX_DIRECTORS of them at a time, so the
+// runs take turns and a scorecard that starts later isn't starved by the ones that started before it.
+func globalDirectorSlots(size int) *semaphore.Weighted {
+	directorSlotsOnce.Do(func() {
+		directorSlots = semaphore.NewWeighted(int64(size))
+	})
+	return directorSlots
+}
+
+// withDirectorSlot runs the director of a region once it has a slot, it fails if ctx is done before that
+func withDirectorSlot(ctx context.Context, slots *semaphore.Weighted, run func(ctx context.Context) error) error {
+	if err := slots.Acquire(ctx, 1); err != nil {
+		return fmt.Errorf("manager Run error waiting for a director slot: %w", err)
+	}
+	defer slots.Release(1)
+	return run(ctx)
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// the directors of two runs are capped by the limit of each run and by the slots they share
+func Test_withDirectorSlot(t *testing.T) {
+	slots := semaphore.NewWeighted(3)
+	var running, maxRunning atomic.Int64
+	var lock sync.Mutex
+	var order []string // the run of each director, in the order they started
+	director := func(runName string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			lock.Lock()
+			order = append(order, runName)
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}
+	}
+	var runs sync.WaitGroup
+	for _, runName := range []string{"a", "b"} {
+		runs.Add(1)
+		go func() {
+			defer runs.Done()
+			errGroup, ctx := errgroup.WithContext(context.Background())
+			errGroup.SetLimit(2)
+			for i := 0; i < 6; i++ {
+				errGroup.Go(func() error { return withDirectorSlot(ctx, slots, director(runName)) })
+			}
+			if err := errGroup.Wait(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	runs.Wait()
+	if max := maxRunning.Load(); max > 3 {
+		t.Errorf("%v directors ran at once, want at most 3", max)
+	}
+	// neither run waited for the other to finish, each run had directors among the first half to start
+	firstHalf := map[string]bool{}
+	for _, runName := range order[:6] {
+		firstHalf[runName] = true
+	}
+	if len(order) != 12 || len(firstHalf) != 2 {
+		t.Errorf("the directors started in the order %v, want the runs to take turns", order)
+	}
+
+	// a run that is cancelled while it waits for a slot doesn't run its director
+	if err := slots.Acquire(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	defer slots.Release(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := withDirectorSlot(ctx, slots, func(context.Context) error {
+		t.Error("the director ran without a slot")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("withDirectorSlot() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,232 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// DryRunReport lists the cells of a scorecard with the queries that processing it would run,
+// and the problems that would make cells or the whole run fail
+type DryRunReport struct {
+	DocumentID string                `json:"document_id"`
+	FromSecs   int64                 `json:"from_secs"`
+	ToSecs     int64                 `json:"to_secs"`
+	Thresholds *scorecard.Thresholds `json:"thresholds,omitempty"`  // the significance thresholds, unless they are invalid
+	Exclusions []builder.TimeWindow  `json:"exclusions,omitempty"`  // the valid times that are left out of the statistics
+	HourFilter *builder.HourFilter   `json:"hour_filter,omitempty"` // the valid and init hours that the statistics use
+	Cells      int                   `json:"cells"`
+	Queries    int                   `json:"queries"`            // distinct statements, each one is run once
+	Problems   []string              `json:"problems,omitempty"` // problems of the document, blocks or regions
+	Regions    []DryRunRegion        `json:"regions"`
+	statements map[string]bool       // to count the distinct statements
+}
+
+// DryRunRegion is the part of a DryRunReport for one region of a block
+type DryRunRegion struct {
+	Block       string              `json:"block"`
+	Region      string              `json:"region"`
+	Application string              `json:"application"`
+	Cells       []director.CellPlan `json:"cells"`
+}
+
+// ProblemCount is the number of problems in the report, including the problems of the cells
+func (report *DryRunReport) ProblemCount() int {
+	count := len(report.Problems)
+	for _, region := range report.Regions {
+		for _, c := range region.Cells {
+			count += len(c.Problems)
+		}
+	}
+	return count
+}
+
+// WriteText writes the report as text, one cell per line followed by its problems
+func (report *DryRunReport) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dry run of %s from %d to %d: %d cells, %d queries, %d problems\n",
+		report.DocumentID, report.FromSecs, report.ToSecs, report.Cells, report.Queries, report.ProblemCount())
+	if t := report.Thresholds; t != nil {
+		fmt.Fprintf(&b, "thresholds by %s: minor %s (%.2f%%) major %s (%.2f%%)\n", t.PercentStdv, t.Minor, t.MinorPercent, t.Major, t.MajorPercent)
+	}
+	if f := report.HourFilter; f != nil {
+		fmt.Fprintf(&b, "valid hours: %v init hours: %v\n", f.ValidHours, f.InitHours)
+	}
+	for _, window := range report.Exclusions {
+		fmt.Fprintf(&b, "excluded: %d to %d\n", window.FromSecs, window.ToSecs)
+	}
+	for _, problem := range report.Problems {
+		fmt.Fprintf(&b, "problem: %s\n", problem)
+	}
+	for _, region := range report.Regions {
+		for _, c := range region.Cells {
+			fmt.Fprintf(&b, "%s [%s %s]\n", c.Path, c.StatisticType, c.DataType)
+			for _, problem := range c.Problems {
+				fmt.Fprintf(&b, "  problem: %s\n", problem)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func newDryRunReport(documentID string) *DryRunReport {
+	return &DryRunReport{DocumentID: documentID, statements: map[string]bool{}}
+}
+
+func (report *DryRunReport) addProblem(format string, a ...any) {
+	report.Problems = append(report.Problems, fmt.Sprintf(format, a...))
+}
+
+func (report *DryRunReport) addRegion(region DryRunRegion) {
+	for _, c := range region.Cells {
+		for _, stmnt := range []string{c.ControlQuery, c.ExperimentalQuery} {
+			if stmnt != "" {
+				report.statements[stmnt] = true
+			}
+		}
+	}
+	report.Cells += len(region.Cells)
+	report.Queries = len(report.statements)
+	report.Regions = append(report.Regions, region)
+}
+
+// statementCells returns the distinct statements of the report in cell order, and the path of the first cell of each
+func (report *DryRunReport) statementCells() (stmnts []string, cells map[string]string) {
+	cells = map[string]string{}
+	for _, region := range report.Regions {
+		for _, c := range region.Cells {
+			for _, stmnt := range []string{c.ControlQuery, c.ExperimentalQuery} {
+				if _, seen := cells[stmnt]; stmnt != "" && !seen {
+					cells[stmnt] = c.Path
+					stmnts = append(stmnts, stmnt)
+				}
+			}
+		}
+	}
+	return stmnts, cells
+}
+
+// DryRun reads the scorecard document and renders and checks the query templates of every cell
+// like Run would, but it does not connect to MySQL, write results or status to the document,
+// or notify the scorecard app. An error is returned if the document can't be read, problems
+// with its contents are in the report.
+func (mngr *Manager) DryRun(ctx context.Context) (*DryRunReport, error) {
+	report := newDryRunReport(mngr.documentID)
+	closeStore, err := mngr.connectStore()
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error: %w", err)
+	}
+	defer closeStore()
+	// the queries of a document with the wrong structure can't be rendered, its problems are the report
+	err = mngr.validateDocument(ctx)
+	var invalid *scorecard.ValidationError
+	if errors.As(err, &invalid) {
+		for _, p := range invalid.Problems {
+			report.addProblem("%s", p)
+		}
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error: %w", err)
+	}
+	resultsBlocks, err := mngr.getBlocks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error getting resultsBlocks: %w", err)
+	}
+	queryBlocks, err := mngr.getQueryBlocks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error getting queryBlocks: %w", err)
+	}
+	plotParams, err := mngr.getPlotParams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error getting plotParams: %w", err)
+	}
+	curves, err := mngr.getPlotParamCurves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error getting plotParamCurves: %w", err)
+	}
+	dateRange, err := mngr.getDateRange(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager DryRun error getting daterange: %w", err)
+	}
+	mngr.dryRunDocument(report, resultsBlocks, queryBlocks, plotParams, curves, dateRange)
+	return report, nil
+}
+
+// dryRunDocument adds the blocks of the document sections that DryRun read to the report
+func (mngr *Manager) dryRunDocument(
+	report *DryRunReport,
+	resultsBlocks map[string]*scorecard.ResultsBlock,
+	queryBlocks map[string]*scorecard.QueryBlock,
+	plotParams scorecard.PlotParams,
+	curves []scorecard.Curve,
+	dateRange director.DateRange,
+) {
+	report.FromSecs = dateRange.FromSecs
+	report.ToSecs = dateRange.ToSecs
+	thresholds, err := plotParams.Thresholds()
+	if err != nil {
+		report.addProblem("the thresholds are invalid: %v", err)
+	} else {
+		report.Thresholds = &thresholds
+	}
+	report.Exclusions, err = mngr.exclusionWindows(plotParams)
+	if err != nil {
+		report.addProblem("the exclusions are invalid: %v", err)
+	}
+	if hourFilter := (builder.HourFilter{ValidHours: plotParams.ValidHours, InitHours: plotParams.InitHours}); !hourFilter.IsZero() {
+		report.HourFilter = &hourFilter
+	}
+	for _, blockName := range sortedKeys(resultsBlocks) {
+		mngr.dryRunBlock(report, blockName, resultsBlocks[blockName], queryBlocks[blockName], curves, dateRange)
+	}
+}
+
+// dryRunBlock adds the regions of a block to the report
+func (mngr *Manager) dryRunBlock(report *DryRunReport, blockName string, block *scorecard.ResultsBlock, queryBlock *scorecard.QueryBlock, curves []scorecard.Curve, dateRange director.DateRange) {
+	if block == nil {
+		report.addProblem("block %q is empty", blockName)
+		return
+	}
+	if queryBlock == nil {
+		report.addProblem("block %q has no queryMap block", blockName)
+		return
+	}
+	appName, templateVariables, err := blockCurve(curves, block.BlockTitle.Label)
+	if err != nil {
+		report.addProblem("block %q has no plotParams curve with the label %q", blockName, block.BlockTitle.Label)
+		return
+	}
+	if strings.ToUpper(appName) == "CB" {
+		report.addProblem("block %q uses the unimplemented Couchbase director", blockName)
+		return
+	}
+	regionNames := sortedKeys(block.Data)
+	queryRegionNames := sortedKeys(queryBlock.Data)
+	if !reflect.DeepEqual(regionNames, queryRegionNames) {
+		report.addProblem("block %q regions %v do not equal its query regions %v", blockName, regionNames, queryRegionNames)
+		return
+	}
+	for _, regionName := range regionNames {
+		dryRunDirector, err := director.GetDryRunDirector("MysqlDirector", dateRange)
+		if err != nil {
+			report.addProblem("block %q region %q: %v", blockName, regionName, err)
+			continue
+		}
+		dryRunDirector.SetTemplateVariables(templateVariables)
+		plans, err := dryRunDirector.DryRun(regionName, block.Data[regionName], queryBlock.Data[regionName])
+		if err != nil {
+			report.addProblem("block %q region %q: %v", blockName, regionName, err)
+			continue
+		}
+		report.addRegion(DryRunRegion{Block: blockName, Region: regionName, Application: appName, Cells: plans})
+	}
+}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// loadTestScorecard reads a scorecard document from testdata
+func loadTestScorecard(t *testing.T, fileName string) *scorecard.Document {
+	t.Helper()
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("error reading %s: %v", fileName, err)
+	}
+	var doc *scorecard.Document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("error unmarshalling %s: %v", fileName, err)
+	}
+	return doc
+}
+
+func Test_dryRunDocument(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakDoc    func(doc *scorecard.Document)
+		wantProblem string
+	}{
+		{name: "valid"},
+		{
+			name: "unknown placeholder",
+			breakDoc: func(doc *scorecard.Document) {
+				setFirstControlTemplate(doc, "SELECT {{bogus}} AS stat")
+			},
+			wantProblem: "unknown placeholders [bogus]",
+		},
+		{
+			name: "missing curve",
+			breakDoc: func(doc *scorecard.Document) {
+				doc.PlotParams.Curves = nil
+			},
+			wantProblem: "has no plotParams curve",
+		},
+		{
+			name: "missing query block",
+			breakDoc: func(doc *scorecard.Document) {
+				doc.QueryMap.Blocks = nil
+			},
+			wantProblem: "has no queryMap block",
+		},
+		{
+			name: "thresholds",
+			breakDoc: func(doc *scorecard.Document) {
+				doc.PlotParams.MajorThresholdByPercent = scorecard.Setting{Text: "90"}
+			},
+			wantProblem: "the thresholds are invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := loadTestScorecard(t, "./testdata/test_Surface.json")
+			if tt.breakDoc != nil {
+				tt.breakDoc(doc)
+			}
+			mngr := &Manager{documentID: "SCTEST:test_Surface"}
+			report := newDryRunReport(mngr.documentID)
+			mngr.dryRunDocument(report, doc.Results.Blocks, doc.QueryMap.Blocks, doc.PlotParams, doc.PlotParams.Curves,
+				director.DateRange{FromSecs: 1679688000, ToSecs: 1682254800})
+
+			var text strings.Builder
+			if err := report.WriteText(&text); err != nil {
+				t.Fatalf("WriteText() error %v", err)
+			}
+			if tt.wantProblem == "" {
+				if report.ProblemCount() != 0 || report.Cells == 0 || report.Queries == 0 || report.Queries > 2*report.Cells {
+					t.Errorf("dryRunDocument() %d cells %d queries, problems:\n%s", report.Cells, report.Queries, text.String())
+				}
+				if report.Thresholds == nil || !strings.Contains(text.String(), "thresholds by Percent: minor 95 (95.00%) major 99 (99.00%)") {
+					t.Errorf("dryRunDocument() thresholds %v, report:\n%s", report.Thresholds, text.String())
+				}
+				if stmnts, cells := report.statementCells(); len(stmnts) != report.Queries || cells[stmnts[0]] != report.Regions[0].Cells[0].Path {
+					t.Errorf("statementCells() returned %d statements, want %d", len(stmnts), report.Queries)
+				}
+				return
+			}
+			if report.ProblemCount() == 0 || !strings.Contains(text.String(), tt.wantProblem) {
+				t.Errorf("dryRunDocument() report doesn't have the problem %q:\n%s", tt.wantProblem, text.String())
+			}
+		})
+	}
+}
+
+// setFirstControlTemplate sets the control query template of the first queryMap leaf, in key order
+func setFirstControlTemplate(doc *scorecard.Document, template string) {
+	blockName := sortedKeys(doc.QueryMap.Blocks)[0]
+	queryData := doc.QueryMap.Blocks[blockName].Data
+	queryRegion := queryData[sortedKeys(queryData)[0]]
+	keys := queryRegion.Keys()[0]
+	leaf, _ := queryRegion.Get(keys)
+	leaf.ControlQueryTemplate = template
+	queryRegion.Set(keys, leaf)
+}
@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/daterange"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// exclusionSeparator separates the date ranges of PROC_EXCLUSIONS, e.g.
+// "2023-03-01T00:00Z/2023-03-03T00:00Z; 04/10/2023 00:00 - 04/10/2023 12:00"
+const exclusionSeparator = ";"
+
+// splitExclusions returns the date ranges of a PROC_EXCLUSIONS value
+func splitExclusions(value string) []string {
+	var ranges []string
+	for _, r := range strings.Split(value, exclusionSeparator) {
+		if r = strings.TrimSpace(r); r != "" {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// parseExclusions converts date ranges to the time windows that the builder leaves out of the statistics,
+// relative date ranges are relative to now
+func parseExclusions(ranges []string, now time.Time) ([]builder.TimeWindow, error) {
+	windows := make([]builder.TimeWindow, 0, len(ranges))
+	for _, s := range ranges {
+		r, err := daterange.Parse(s, now)
+		if err != nil {
+			return nil, fmt.Errorf("manager exclusion error %w", err)
+		}
+		windows = append(windows, builder.TimeWindow{FromSecs: r.From.Unix(), ToSecs: r.To.Unix()})
+	}
+	return windows, nil
+}
+
+// exclusionWindows returns the time windows that a run leaves out of the statistics,
+// the global ones of PROC_EXCLUSIONS and the ones of the scorecard's plotParams
+func (mngr *Manager) exclusionWindows(plotParams scorecard.PlotParams) ([]builder.TimeWindow, error) {
+	ranges := append(append([]string{}, mngr.config.Exclusions...), plotParams.Exclusions...)
+	return parseExclusions(ranges, time.Now())
+}
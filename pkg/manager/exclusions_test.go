@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func Test_splitExclusions(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: nil},
+		{value: " ; ", want: nil},
+		{value: "2023-03-01/P2D", want: []string{"2023-03-01/P2D"}},
+		{value: "2023-03-01/P2D; 04/10/2023 00:00 - 04/10/2023 12:00;", want: []string{"2023-03-01/P2D", "04/10/2023 00:00 - 04/10/2023 12:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := splitExclusions(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitExclusions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_exclusionWindows(t *testing.T) {
+	mngr := &Manager{config: Config{Exclusions: []string{"2023-03-01T00:00Z/P2D"}}}
+	plotParams := scorecard.PlotParams{Exclusions: []string{"04/10/2023 00:00 - 04/10/2023 12:00"}}
+	got, err := mngr.exclusionWindows(plotParams)
+	if err != nil {
+		t.Fatalf("exclusionWindows() error = %v", err)
+	}
+	want := []builder.TimeWindow{{FromSecs: 1677628800, ToSecs: 1677801600}, {FromSecs: 1681084800, ToSecs: 1681128000}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exclusionWindows() = %v, want %v", got, want)
+	}
+
+	plotParams.Exclusions = append(plotParams.Exclusions, "yesterday")
+	if _, err := mngr.exclusionWindows(plotParams); err == nil || !strings.Contains(err.Error(), "manager exclusion error") {
+		t.Errorf("exclusionWindows() error = %v, want a manager exclusion error", err)
+	}
+}
+
+func Test_loadConfig_exclusions(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "ranges", value: "2023-03-01/P2D;last 2 days", want: []string{"2023-03-01/P2D", "last 2 days"}},
+		{name: "invalid", value: "2023-03-01/P2D;2023-03-05", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROC_EXCLUSIONS", tt.value)
+			if tt.value == "" {
+				_ = os.Unsetenv("PROC_EXCLUSIONS")
+			}
+			config, err := loadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(config.Exclusions, tt.want) {
+				t.Errorf("loadConfig() Exclusions = %q, want %q", config.Exclusions, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseExclusions_relative(t *testing.T) {
+	now := time.Date(2023, 4, 23, 13, 45, 0, 0, time.UTC)
+	got, err := parseExclusions([]string{"last 1 day ending at the latest 00Z"}, now)
+	if err != nil {
+		t.Fatalf("parseExclusions() error = %v", err)
+	}
+	want := []builder.TimeWindow{{FromSecs: now.Truncate(24*time.Hour).Unix() - 86400, ToSecs: now.Truncate(24 * time.Hour).Unix()}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExclusions() = %v, want %v", got, want)
+	}
+}
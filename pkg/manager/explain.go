@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// explainQueries is the query cost pre-flight. It EXPLAINs every distinct statement of the scorecard
+// before any of them is run and logs the expensive ones, which are kept for QueryCostFindings.
+// In refuse mode an expensive query fails the run.
+func (mngr *Manager) explainQueries(
+	ctx context.Context,
+	resultsBlocks map[string]*scorecard.ResultsBlock,
+	queryBlocks map[string]*scorecard.QueryBlock,
+	plotParams scorecard.PlotParams,
+	curves []scorecard.Curve,
+	dateRange director.DateRange,
+) error {
+	// the statements are rendered the same way as for a dry run, template problems are left for Run to report
+	report := newDryRunReport(mngr.documentID)
+	mngr.dryRunDocument(report, resultsBlocks, queryBlocks, plotParams, curves, dateRange)
+	stmnts, cells := report.statementCells()
+	explainer, err := director.GetDirector("MysqlDirector", mngr.mysqlPool, dateRange, 0, 0)
+	if err != nil {
+		return fmt.Errorf("manager explainQueries error getting director: %w", err)
+	}
+	explainer.SetWorkers(mngr.config.DirectorWorkers)
+	explainer.SetQueryTimeout(mngr.config.QueryTimeout)
+	explainer.SetRetryPolicy(mngr.config.Retry)
+	findings, err := explainer.ExplainQueries(ctx, stmnts, int64(mngr.config.ExplainMaxRows))
+	if err != nil {
+		return fmt.Errorf("manager explainQueries error: %w", err)
+	}
+	for i := range findings {
+		findings[i].Cell = cells[findings[i].Statement]
+		log.Printf("manager expensive query for %q table %s: %v", findings[i].Cell, findings[i].Table, findings[i].Problems)
+	}
+	mngr.queryCostFindings = findings
+	log.Printf("manager explained %d queries, %d tables are too expensive to read", len(stmnts), len(findings))
+	if len(findings) > 0 && mngr.config.ExplainMode == ExplainRefuse {
+		return fmt.Errorf("manager explainQueries refused the scorecard, %d tables are too expensive to read, the first for %q: %v",
+			len(findings), findings[0].Cell, findings[0].Problems)
+	}
+	return nil
+}
+
+// QueryCostFindings returns the expensive queries that the query cost pre-flight of the last Run found
+func (mngr *Manager) QueryCostFindings() []director.QueryCostFinding {
+	return mngr.queryCostFindings
+}
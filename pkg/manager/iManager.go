@@ -2,7 +2,7 @@ package manager
 
 /*
 A Manager is the entry point for the data processing.
-A Manager holds a couchbase connection, a scorecard document id,
+A Manager holds a document store (usually Couchbase), a scorecard document id,
 and a scorecard (ScorecardBlock) (which is what it
 retrieves from couchbase using the id)
 
@@ -18,56 +18,88 @@ There may be many upserts before the documement is fully
 processed.
 */
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
-	"github.com/couchbase/gocb/v2"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
 )
 
-type cbConnection struct {
-	Cluster    *gocb.Cluster
-	Bucket     *gocb.Bucket
-	Scope      *gocb.Scope
-	Collection *gocb.Collection
-}
-
 type Manager struct {
 	documentID string
-	cb         *cbConnection
+	store      DocumentStore            // Couchbase unless the manager was given another store
+	ownsStore  bool                     // the manager connected the store and closes it
+	queryCache *director.QueryCache     // shared by all the directors of a run
+	mysqlPool  *director.ConnectionPool // shared by all the directors of all the runs of the process
+	config     Config
+	// notify the scorecard app about the progress and the status of the document
+	notifyScorecardApp bool
+	// called with the progress of a run, may be nil
+	progressListener func(Progress)
+	// the expensive queries that the query cost pre-flight found
+	queryCostFindings []director.QueryCostFinding
+	// the cells that a run processes, see SetResumeMode
+	resumeMode ResumeMode
+	// the regions that a run completed, shared by all the regions of the run
+	checkpoint *checkpointer
+	// the part of the document that a run processes, see SetScope
+	scope Scope
+	// the valid times that a run leaves out of the statistics, from PROC_EXCLUSIONS and the plotParams
+	exclusions []builder.TimeWindow
+	// the valid and init hours of the matched records that a run uses, from the plotParams
+	hourFilter builder.HourFilter
 }
 
 type ManagerBuilder interface {
-	Run() error
+	Run(ctx context.Context) error
+	DryRun(ctx context.Context) (*DryRunReport, error)
+	QueryCostFindings() []director.QueryCostFinding
+	Validate(ctx context.Context) ([]scorecard.Problem, error)
 	close() error
-	SetStatus(status string)
+	SetStatus(status string) error
+	SetDocumentStore(store DocumentStore)
+	connectStore() (closeStore func(), err error)
 	SetProcessedAt() error
 	loadEnvironment() (mysqlCredentials, cbCredentials director.DbCredentials, err error)
 	getCouchbaseConnection(cbCredentials director.DbCredentials) (err error)
-	upsertSubDocument(path string, subDoc interface{}) error
-	getSubDocument(path string, subDocPtr *interface{}) error
-	getBlocks(map[string]interface{}, error)
-	getQueryBlocks(map[string]interface{}, error)
-	getPlotParams(map[string]interface{}, error)
-	getPlotParamCurves([]map[string]interface{}, error)
-	getDateRange(director.DateRange, error)
-	convertStdToPercent(std string) (percent float64, err error)
-	getThresholds(plotParams map[string]interface{}) (minorThreshold, majorThreshold float64, err error)
+	upsertSubDocument(ctx context.Context, path string, subDoc interface{}) error
+	getDocument(ctx context.Context) ([]byte, error)
+	validateDocument(ctx context.Context) error
+	getSubDocument(ctx context.Context, path string, subDocPtr interface{}) error
+	getBlocks(ctx context.Context) (map[string]*scorecard.ResultsBlock, error)
+	getQueryBlocks(ctx context.Context) (map[string]*scorecard.QueryBlock, error)
+	getPlotParams(ctx context.Context) (scorecard.PlotParams, error)
+	getPlotParamCurves(ctx context.Context) ([]scorecard.Curve, error)
+	getDateRange(ctx context.Context) (director.DateRange, error)
+	SetNotifyScorecardApp(notify bool)
+	SetProgressListener(listener func(Progress))
+	reportProgress(ctx context.Context, tracker *progressTracker) (stop func())
+	SetResumeMode(mode ResumeMode)
+	SetScope(scope Scope)
+	loadCheckpoint(ctx context.Context) (*checkpointer, error)
+	completeRegion(ctx context.Context, blockName, regionName string, cells []scorecard.CellKeys, cellErrors map[string]director.ErrorClass) error
+	notifyStatus(scorecardAppURL, status string, err error) error
 	notifyMatsRefresh(scorecardAppURL, docID string) error
 	processRegion(
+		ctx context.Context,
 		appName string,
 		queryRegionName string,
-		queryRegion map[string]interface{},
+		queryRegion scorecard.QueryRegion,
+		blockName string,
 		blockRegionName string,
-		region *interface{},
+		region scorecard.ResultsRegion,
+		cells []scorecard.CellKeys,
 		regionPath string,
-		mysqlCredentials director.DbCredentials,
 		dateRange director.DateRange,
 		minorThreshold float64,
 		majorThreshold float64,
+		templateVariables director.TemplateVariables,
 		documentScorecardAppURL string,
-		cellCountPtr *int,
+		summary *director.RunSummary,
+		counter *regionCounter,
 	) error
 }
 
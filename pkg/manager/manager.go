@@ -11,11 +11,12 @@ the manager is passed the id of the corresponding scorecard document.
 1. The manager will read the scorcard document associated with the id from Couchbase
 and maintain it in memory on behalf of its directors.
 1. The manager will start go workers (which are directors) making sure that the number of
-workers (directors) does not exceed the maximum number of database connections
+database connections does not exceed the maximum number of database connections
 configured for each kind of director. For example currently most apps are legacy apps
-that require a mysql database connection. If the configuration specifies 20 allowed mysql
-database connections the manager will allow up to twenty workers. Each worker is a
-director and each director will maintain its own database connection (e.g. mysql client).
+that require a mysql database connection. The runs of the process share one mysql connection pool
+(director.ConnectionPool, see globalMySQLPool) that is capped at PROC_MYSQL_MAX_OPEN_CONNS connections
+and all of their directors borrow connections from it, so a director waits for a free connection
+instead of opening a new one.
 1. The appname associated with a scorecard block tells the manager what kind of director is
 needed for each scorecard block. Each block requires an associated database query template
 which is included in the scorecard document. The manager will build a queue of sc_element
@@ -43,266 +44,262 @@ and then it politely dies.
 */
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/builder"
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/client"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/daterange"
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
-	"github.com/couchbase/gocb/v2"
-	"golang.org/x/sync/errgroup"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
 )
 
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// loadEnvironment retrieves required settings from the environment
+func (mngr *Manager) loadEnvironment() (mysqlCredentials, cbCredentials director.DbCredentials, err error) {
+	cbCredentials, err = loadCouchbaseEnvironment()
+	if err != nil {
+		return director.DbCredentials{}, director.DbCredentials{}, err
 	}
-	return keys
+	mysqlCredentials, err = loadMySQLEnvironment()
+	if err != nil {
+		return director.DbCredentials{}, director.DbCredentials{}, err
+	}
+	return mysqlCredentials, cbCredentials, nil
 }
 
-// loadEnvironment retrieves required settings from the environment
-func (mngr *Manager) loadEnvironment() (mysqlCredentials, cbCredentials director.DbCredentials, err error) {
+// the keyspace of the scorecard documents in the CB_BUCKET bucket if CB_SCOPE and CB_COLLECTION aren't set
+const (
+	defaultScope      = "_default"
+	defaultCollection = "SCORECARD"
+)
+
+// loadCouchbaseEnvironment retrieves the Couchbase settings from the environment
+func loadCouchbaseEnvironment() (cbCredentials director.DbCredentials, err error) {
 	cbCredentials = director.DbCredentials{
-		Scope:      "_default",
-		Collection: "SCORECARD",
+		Scope:      defaultScope,
+		Collection: defaultCollection,
 		Bucket:     os.Getenv("CB_BUCKET"),
 		Host:       os.Getenv("CB_HOST"),
 	}
+	// the scorecard documents are in the SCORECARD collection of the default scope unless the environment selects another keyspace
+	if scope := os.Getenv("CB_SCOPE"); scope != "" {
+		cbCredentials.Scope = scope
+	}
+	if collection := os.Getenv("CB_COLLECTION"); collection != "" {
+		cbCredentials.Collection = collection
+	}
 
 	if cbCredentials.Host == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined CB_HOST in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined CB_HOST in environment")
 	}
 	cbCredentials.User = os.Getenv("CB_USER")
 	if cbCredentials.User == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined CB_USER in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined CB_USER in environment")
 	}
 	cbCredentials.Password = os.Getenv("CB_PASSWORD")
 	if cbCredentials.Password == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined CB_PASSWORD in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined CB_PASSWORD in environment")
 	}
 	cbCredentials.Bucket = os.Getenv("CB_BUCKET")
 	if cbCredentials.Bucket == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined CB_BUCKET in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined CB_BUCKET in environment")
 	}
+	return cbCredentials, nil
+}
 
+// loadMySQLEnvironment retrieves the MySQL settings from the environment
+func loadMySQLEnvironment() (mysqlCredentials director.DbCredentials, err error) {
 	// refer to https://github.com/go-sql-driver/mysql/#dsn-data-source-name
 	mysqlCredentials.Host = os.Getenv("MYSQL_HOST")
 	if mysqlCredentials.Host == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_HOST in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_HOST in environment")
 	}
 	mysqlCredentials.User = os.Getenv("MYSQL_USER")
 	if mysqlCredentials.User == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_USER in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_USER in environment")
 	}
 	mysqlCredentials.Password = os.Getenv("MYSQL_PASSWORD")
 	if mysqlCredentials.Password == "" {
-		return director.DbCredentials{}, director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_PASSWORD in environment")
+		return director.DbCredentials{}, fmt.Errorf("Undefined MYSQL_PASSWORD in environment")
 	}
-	return mysqlCredentials, cbCredentials, nil
+	return mysqlCredentials, nil
 }
 
 // Close is required after we are finished with a Manager. It usually recommended to
 // call it with defer.
 func (mngr *Manager) close() error {
-	return mngr.cb.Cluster.Close(nil)
+	if mngr.store == nil {
+		return nil
+	}
+	return mngr.store.Close()
 }
 
-// getCouchbaseConnection establishes the couchbase connection
-// mysql connections are maintained in the mysql_director
+// SetDocumentStore makes the manager read and write its document in store instead of in Couchbase.
+// The caller owns the store, the manager doesn't close it.
+func (mngr *Manager) SetDocumentStore(store DocumentStore) {
+	mngr.store = store
+	mngr.ownsStore = false
+}
+
+// getCouchbaseConnection establishes the couchbase connection and makes it the document store of the manager
+// mysql connections are maintained in the director.ConnectionPool
 func (mngr *Manager) getCouchbaseConnection(cbCredentials director.DbCredentials) (err error) {
-	options := gocb.ClusterOptions{
-		Authenticator: gocb.PasswordAuthenticator{
-			Username: cbCredentials.User,
-			Password: cbCredentials.Password,
-		},
+	store, err := NewCouchbaseStore(cbCredentials, mngr.config.Retry)
+	if err != nil {
+		return err
 	}
-	if err = options.ApplyProfile(gocb.ClusterConfigProfileWanDevelopment); err != nil {
-		return fmt.Errorf("manager gocb ApplyProfile error: %w", err)
+	mngr.store = store
+	mngr.ownsStore = true
+	return nil
+}
+
+// connectStore connects to the Couchbase document store, unless the manager was given a store with
+// SetDocumentStore. The returned function closes a store that was connected here.
+func (mngr *Manager) connectStore() (closeStore func(), err error) {
+	if mngr.store != nil && !mngr.ownsStore {
+		return func() {}, nil
 	}
-	// Initialize the Connection
-	var cluster *gocb.Cluster
-	cluster, err = gocb.Connect("couchbase://"+cbCredentials.Host, options)
+	cbCredentials, err := loadCouchbaseEnvironment()
 	if err != nil {
-		return fmt.Errorf("manager gocb Connect error: %w", err)
+		return nil, fmt.Errorf("manager loadEnvironmant error %w", err)
 	}
-	mngr.cb.Cluster = cluster
-	mngr.cb.Bucket = cluster.Bucket(cbCredentials.Bucket)
-	err = mngr.cb.Bucket.WaitUntilReady(50*time.Second, nil)
+	err = mngr.getCouchbaseConnection(cbCredentials)
 	if err != nil {
-		return fmt.Errorf("manager bucket.WaitUntilReady error: %w", err)
+		return nil, fmt.Errorf("manager GetConnection error: %w", err)
 	}
-	mngr.cb.Scope = mngr.cb.Bucket.Scope(cbCredentials.Scope)
-	mngr.cb.Collection = mngr.cb.Bucket.Collection(cbCredentials.Collection)
-	return nil
+	return func() {
+		_ = mngr.close()
+		mngr.store = nil
+	}, nil
 }
 
-// upsertSubDocument updates a Couchbase subdocument
-func (mngr *Manager) upsertSubDocument(path string, subDoc interface{}) error {
-	mops := []gocb.MutateInSpec{
-		gocb.UpsertSpec(path, subDoc, &gocb.UpsertSpecOptions{}),
-	}
-	upsertResult, err := mngr.cb.Collection.MutateIn(mngr.documentID, mops, &gocb.MutateInOptions{
-		Timeout: 10050 * time.Millisecond,
-	})
+// upsertSubDocument updates a subdocument of the scorecard document
+func (mngr *Manager) upsertSubDocument(ctx context.Context, path string, subDoc interface{}) error {
+	err := mngr.store.UpsertSubDocument(ctx, mngr.documentID, path, subDoc)
 	if err != nil {
 		return fmt.Errorf("manager upsertSubDocument error: %w", err)
 	}
-	// There is probably a better way to do this
-	if upsertResult.MutationToken().BucketName() != "vxdata" {
-		return fmt.Errorf("manager upsertSubDocument result bad upsertResult")
-	}
 	return nil
 }
 
-// getSubDocument retrieves a Couchbase subdocument
-func (mngr *Manager) getSubDocument(path string, subDocPtr *interface{}) error {
-	ops := []gocb.LookupInSpec{
-		gocb.GetSpec(path, &gocb.GetSpecOptions{IsXattr: false}),
-	}
-	getResult, err := mngr.cb.Collection.LookupIn(mngr.documentID, ops, &gocb.LookupInOptions{})
-	if err != nil {
-		return fmt.Errorf("manager getSubDocument LookupIn error %w", err)
-	}
-	err = getResult.ContentAt(0, subDocPtr)
+// getSubDocument retrieves a subdocument of the scorecard document into subDocPtr
+func (mngr *Manager) getSubDocument(ctx context.Context, path string, subDocPtr interface{}) error {
+	err := mngr.store.GetSubDocument(ctx, mngr.documentID, path, subDocPtr)
 	if err != nil {
-		return fmt.Errorf("manager getSubDocument getResult error %w", err)
+		return fmt.Errorf("manager getSubDocument error %w", err)
 	}
 	return nil
 }
 
 // retrieve the results.blocks section of the document by subdoc get
-func (mngr *Manager) getBlocks() (map[string]interface{}, error) {
-	var blocks interface{}
-	err := mngr.getSubDocument("results.blocks", &blocks)
+func (mngr *Manager) getBlocks(ctx context.Context) (map[string]*scorecard.ResultsBlock, error) {
+	var blocks map[string]*scorecard.ResultsBlock
+	err := mngr.getSubDocument(ctx, "results.blocks", &blocks)
 	if err != nil {
 		return nil, fmt.Errorf("manager getBlocks error %w", err)
 	}
-	return blocks.(map[string]interface{}), err
+	return blocks, nil
 }
 
 // retrieve the queryMap.blocks section of the document by subdoc get
-func (mngr *Manager) getQueryBlocks() (map[string]interface{}, error) {
-	var blocks interface{}
-	err := mngr.getSubDocument("queryMap.blocks", &blocks)
+func (mngr *Manager) getQueryBlocks(ctx context.Context) (map[string]*scorecard.QueryBlock, error) {
+	var blocks map[string]*scorecard.QueryBlock
+	err := mngr.getSubDocument(ctx, "queryMap.blocks", &blocks)
 	if err != nil {
 		return nil, fmt.Errorf("manager getQueryBlocks error %w", err)
 	}
-	return blocks.(map[string]interface{}), err
+	return blocks, nil
 }
 
 // retrieve the PlotParams section of the document by subdoc get
-func (mngr *Manager) getPlotParams() (map[string]interface{}, error) {
-	var plotParams interface{}
-	err := mngr.getSubDocument("plotParams", &plotParams)
+func (mngr *Manager) getPlotParams(ctx context.Context) (scorecard.PlotParams, error) {
+	var plotParams scorecard.PlotParams
+	err := mngr.getSubDocument(ctx, "plotParams", &plotParams)
 	if err != nil {
-		return nil, fmt.Errorf("manager getPlotParams error %w", err)
+		return plotParams, fmt.Errorf("manager getPlotParams error %w", err)
 	}
-	return plotParams.(map[string]interface{}), err
+	return plotParams, nil
 }
 
 // retrieve the PlotParam.curves (this is an array) section of the document by subdoc get
-func (mngr *Manager) getPlotParamCurves() ([]map[string]interface{}, error) {
-	var curves interface{}
-	var curveArray []map[string]interface{}
-	err := mngr.getSubDocument("plotParams.curves", &curves)
+func (mngr *Manager) getPlotParamCurves(ctx context.Context) ([]scorecard.Curve, error) {
+	var curves []scorecard.Curve
+	err := mngr.getSubDocument(ctx, "plotParams.curves", &curves)
 	if err != nil {
 		return nil, fmt.Errorf("manager getPlotParamCurves error %w", err)
 	}
-	for _, c := range curves.([]interface{}) {
-		curveArray = append(curveArray, c.(map[string]interface{}))
-	}
-	return curveArray, err
+	return curves, nil
 }
 
 // retrieve the dateRange section of the document by subdoc get
-// and convert it to a dateRange struct
-func (mngr *Manager) getDateRange() (director.DateRange, error) {
-	var datesStr interface{}
-	err := mngr.getSubDocument("dateRange", &datesStr)
-	var dateRange director.DateRange
-	// parse the daterange string
-	// "02/19/2023 20:00 - 03/21/2023 20:00"
+// and convert it to a dateRange struct, see the daterange package for the forms of the date range.
+// A relative date range like "last 30 days" is relative to the time of the run.
+func (mngr *Manager) getDateRange(ctx context.Context) (director.DateRange, error) {
+	var datesStr string
+	err := mngr.getSubDocument(ctx, "dateRange", &datesStr)
 	if err != nil {
-		return dateRange, fmt.Errorf("manager getDateRange error %w", err)
+		return director.DateRange{}, fmt.Errorf("manager getDateRange error %w", err)
 	}
-	dateParts := strings.Split(datesStr.(string), " - ")
-	fromTime, err := time.Parse("01/02/2006 15:04", dateParts[0])
+	r, err := daterange.Parse(datesStr, time.Now())
 	if err != nil {
-		return dateRange, fmt.Errorf("manager getDataRange error converting from date to epoch error %w", err)
+		return director.DateRange{}, fmt.Errorf("manager getDateRange error %w", err)
 	}
-	fromSecs := fromTime.Unix()
-	if err != nil {
-		return dateRange, fmt.Errorf("manager getDataRange error getting date range from document error %w", err)
+	return director.DateRange{FromSecs: r.From.Unix(), ToSecs: r.To.Unix()}, nil
+}
+
+// blockCurve finds the plotParams curve of a block by the label in the blockTitle of the block
+// and returns its application and the scorecard variables for the query templates
+func blockCurve(curves []scorecard.Curve, label string) (appName string, templateVariables director.TemplateVariables, err error) {
+	for _, curve := range curves {
+		if curve.Label != label {
+			continue
+		}
+		// the curve parameters are the scorecard variables for the query templates
+		params, err := curve.Parameters()
+		if err != nil {
+			return "", nil, err
+		}
+		return curve.Application, director.ScorecardTemplateVariables(params), nil
 	}
-	toTime, err := time.Parse("01/02/2006 15:04", dateParts[1])
-	if err != nil {
-		return dateRange, fmt.Errorf("manager getDataRange error converting from date to epoch error %w", err)
+	return "", nil, fmt.Errorf("manager there is no plotParams curve with the label %q", label)
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	toSecs := toTime.Unix()
-	dateRange.FromSecs = fromSecs
-	dateRange.ToSecs = toSecs
-	return dateRange, err
+	sort.Strings(keys)
+	return keys
 }
 
-// convertStdToPercent converts a standard deviation to a percent error
-func (mngr *Manager) convertStdToPercent(std string) (percent float64, err error) {
-	stdfloat, err := strconv.ParseFloat(std, 64)
-	if err != nil {
-		err = fmt.Errorf("manager convertStdToPercent error converting standard deviation %q to percent error: %w", std, err)
-		return 0, err
-	}
-	// round to nearest int - should be 1, 2, or 3 - fractions are not allowed
-	stdint := int(stdfloat + 0.5)
-	switch stdint {
-	case 1:
-		percent = 68
-	case 2:
-		percent = 95
-	case 3:
-		percent = 99.7
-	default:
-		err = fmt.Errorf("manager convertStdToPercent error converting standard deviation %q - not between 1 and 3 inclusive", std)
-		return 0, err
-	}
-	return percent, err
+// SetNotifyScorecardApp turns the notifications of the MATS scorecard app about the progress and the status
+// of the document on or off, they are on by default. They are turned off for a document that isn't in Couchbase.
+func (mngr *Manager) SetNotifyScorecardApp(notify bool) {
+	mngr.notifyScorecardApp = notify
 }
 
-// getThresholds extracts the major and minor thresholds
-func (mngr *Manager) getThresholds(plotParams map[string]interface{}) (minorThreshold, majorThreshold float64, err error) {
-	percentStddev := plotParams["scorecard-percent-stdv"]
-	switch percentStddev {
-	case "Percent":
-		minorThreshold, err = strconv.ParseFloat(plotParams["minor-threshold-by-percent"].(string), 64)
-		if err != nil {
-			return minorThreshold, majorThreshold, err
-		}
-		majorThreshold, err = strconv.ParseFloat(plotParams["major-threshold-by-percent"].(string), 64)
-		if err != nil {
-			return minorThreshold, majorThreshold, err
-		}
-	case "Standard Deviation":
-		minorThreshold, err = mngr.convertStdToPercent(plotParams["minor-threshold-by-stdv"].(string))
-		if err != nil {
-			return minorThreshold, majorThreshold, err
-		}
-		majorThreshold, err = mngr.convertStdToPercent(plotParams["major-threshold-by-stdv"].(string))
-		if err != nil {
-			return minorThreshold, majorThreshold, err
-		}
-	default:
-		return minorThreshold, majorThreshold, fmt.Errorf("manager Run error getting threshold percentages")
+// notifyStatus notifies the MATS scorecard app of the status of the document, if the notifications are on
+func (mngr *Manager) notifyStatus(scorecardAppURL, status string, err error) error {
+	if !mngr.notifyScorecardApp {
+		return nil
 	}
-	return minorThreshold, majorThreshold, nil
+	return client.NotifyScorecardStatus(scorecardAppURL, mngr.documentID, status, err)
 }
 
-// notifyMatsRefreash notifies the MATS scorecard app that a particular docID has been updated
+// notifyMatsRefreash notifies the MATS scorecard app that a particular docID has been updated, if the notifications are on
 func (mngr *Manager) notifyMatsRefresh(scorecardAppURL, docID string) error {
+	if !mngr.notifyScorecardApp {
+		return nil
+	}
 	err := client.NotifyScorecard(scorecardAppURL, docID)
 	if err != nil {
 		return fmt.Errorf("manager notifyMATSRefresh error: %w", err)
@@ -311,38 +308,69 @@ func (mngr *Manager) notifyMatsRefresh(scorecardAppURL, docID string) error {
 }
 
 func (mngr *Manager) processRegion(
+	ctx context.Context,
 	appName string,
 	queryRegionName string,
-	queryRegion map[string]interface{},
+	queryRegion scorecard.QueryRegion,
+	blockName string,
 	blockRegionName string,
-	region *interface{},
+	region scorecard.ResultsRegion,
+	cells []scorecard.CellKeys,
 	regionPath string,
-	mysqlCredentials director.DbCredentials,
 	dateRange director.DateRange,
 	minorThreshold float64,
 	majorThreshold float64,
+	templateVariables director.TemplateVariables,
 	documentScorecardAppURL string,
-	cellCountPtr *int,
+	summary *director.RunSummary,
+	counter *regionCounter,
 ) error {
 	if strings.ToUpper(appName) == "CB" {
 		return fmt.Errorf("Couchbase director is unimplemented")
 	}
 	// launch mysql director
-	mysqlDirector, err := director.GetDirector("MysqlDirector", mysqlCredentials, dateRange, minorThreshold, majorThreshold)
+	mysqlDirector, err := director.GetDirector("MysqlDirector", mngr.mysqlPool, dateRange, minorThreshold, majorThreshold)
 	if err != nil {
 		return fmt.Errorf("manager Run error getting director: %w", err)
 	}
-	defer mysqlDirector.CloseDB()
+	mysqlDirector.SetTemplateVariables(templateVariables)
+	mysqlDirector.SetQueryCache(mngr.queryCache)
+	mysqlDirector.SetWorkers(mngr.config.DirectorWorkers)
+	mysqlDirector.SetQueryTimeout(mngr.config.QueryTimeout)
+	mysqlDirector.SetQueryChunk(mngr.config.QueryChunk)
+	mysqlDirector.SetRetryPolicy(mngr.config.Retry)
+	mysqlDirector.SetCellObserver(counter)
+	mysqlDirector.SetExclusions(mngr.exclusions)
+	mysqlDirector.SetHourFilter(mngr.hourFilter)
 
-	*region, err = mysqlDirector.Run(queryRegionName, *region, queryRegion, cellCountPtr)
+	// a resumed region only processes some of its cells, the others keep their values
+	directorRegion, directorQueryRegion := region, queryRegion
+	if cells != nil {
+		directorRegion, directorQueryRegion = region.Subset(cells), queryRegion.Subset(cells)
+	}
+	err = mysqlDirector.Run(ctx, queryRegionName, directorRegion, directorQueryRegion, summary)
 	if err != nil {
 		return fmt.Errorf("manager Run error running director: %w", err)
 	}
+	for _, k := range cells {
+		value, _ := directorRegion.Get(k)
+		region.Set(k, value)
+	}
 
-	err = mngr.upsertSubDocument(regionPath, region)
+	// a scoped run only writes the results of its cells
+	var results interface{} = region
+	if len(mngr.scope.keys) > 0 {
+		results, _ = region.Subtree(mngr.scope.keys)
+		regionPath += "." + subDocPath(mngr.scope.keys...)
+	}
+	err = mngr.upsertSubDocument(ctx, regionPath, results)
 	if err != nil {
 		return fmt.Errorf("manager Run error upserting resultRegion: %q error: %w", blockRegionName, err)
 	}
+	err = mngr.completeRegion(ctx, blockName, blockRegionName, cells, counter.cellErrors())
+	if err != nil {
+		return fmt.Errorf("manager Run error completing resultRegion: %q error: %w", blockRegionName, err)
+	}
 
 	// notify server to update with scorecardApUrl
 	// try to get the SCORECARD_APP_URL from the environment
@@ -358,217 +386,308 @@ func (mngr *Manager) processRegion(
 	return nil
 }
 
-// Run processes the docID associated with the manager
-func (mngr *Manager) Run() (err error) {
+// Run processes the docID associated with the manager. Cancelling ctx stops the directors and their
+// in-flight queries, and the run fails if it takes longer than the configured scorecard timeout.
+func (mngr *Manager) Run(ctx context.Context) (err error) {
 	// load the environment
-	cellCount := 0
+	// the directors run concurrently and count their cells and cell errors in the summary
+	var summary director.RunSummary
 	start := time.Now()
 	// initially unknown
-	mysqlCredentials, cbCredentials, err := mngr.loadEnvironment()
+	mysqlCredentials, err := loadMySQLEnvironment()
 	if err != nil {
 		return fmt.Errorf("manager loadEnvironmant error %w", err)
 	}
-	err = mngr.getCouchbaseConnection(cbCredentials)
+	mngr.config, err = loadConfig()
+	if err != nil {
+		return fmt.Errorf("manager loadConfig error %w", err)
+	}
+	// all of the directors in this run share one query cache
+	mngr.queryCache = director.NewQueryCacheSize(mngr.config.QueryCacheEntries)
+	if mngr.config.ScorecardTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, mngr.config.ScorecardTimeout,
+			fmt.Errorf("manager Run scorecard timeout of %v exceeded", mngr.config.ScorecardTimeout))
+		defer cancel()
+	}
+	closeStore, err := mngr.connectStore()
 	if err != nil {
-		return fmt.Errorf("manager Run GetConnection error: %w", err)
+		return fmt.Errorf("manager Run error: %w", err)
 	}
-	defer mngr.cb.Cluster.Close(nil)
+	defer closeStore()
 	// from here on we should be able to set an error status in the document, if we need to
-	resultsBlocks, err := mngr.getBlocks()
+	// a document with the wrong structure is rejected before anything is processed
+	err = mngr.validateDocument(ctx)
+	if err != nil {
+		_ = mngr.SetStatus("error")
+		return fmt.Errorf("manager Run error validating the document: %w", err)
+	}
+	resultsBlocks, err := mngr.getBlocks(ctx)
 	if err != nil {
 		_ = mngr.SetStatus("error")
 		return fmt.Errorf("manager Run error getting resultsBlocks: %w", err)
 	}
-	blockKeys := getMapKeys(resultsBlocks)
-	sort.Strings(blockKeys)
+	blockKeys := sortedKeys(resultsBlocks)
+	if len(blockKeys) == 0 || resultsBlocks[blockKeys[0]] == nil {
+		_ = mngr.SetStatus("error")
+		return fmt.Errorf("manager Run error the document has no results blocks")
+	}
 	// get the appUrl from the first block - they should all be the same
-	scorecardAppUrl := resultsBlocks[blockKeys[0]].(map[string]interface{})["blockApplication"].(string)
+	scorecardAppUrl := resultsBlocks[blockKeys[0]].BlockApplication
 	// from this point on, we can notify the scorecard app with the status and error
-	queryBlocks, err := mngr.getQueryBlocks()
+	queryBlocks, err := mngr.getQueryBlocks(ctx)
 	if err != nil {
 		_ = mngr.SetStatus("error")
 		err := fmt.Errorf("manager Run error getting queryBlocks: %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		return err
 	}
-	plotParams, err := mngr.getPlotParams()
+	plotParams, err := mngr.getPlotParams(ctx)
 	if err != nil {
 		err := fmt.Errorf("manager Run error getting plotParamCurves: %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		_ = mngr.SetStatus("error")
 		return err
 	}
-	minorThreshold, majorThreshold, err := mngr.getThresholds(plotParams)
+	thresholds, err := plotParams.Thresholds()
 	if err != nil {
 		err := fmt.Errorf("manager Run error getting thresholds: %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		_ = mngr.SetStatus("error")
 		return err
 	}
-	curves, err := mngr.getPlotParamCurves()
+	mngr.exclusions, err = mngr.exclusionWindows(plotParams)
+	if err != nil {
+		err := fmt.Errorf("manager Run error getting the exclusions: %w", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+		_ = mngr.SetStatus("error")
+		return err
+	}
+	mngr.hourFilter = builder.HourFilter{ValidHours: plotParams.ValidHours, InitHours: plotParams.InitHours}
+	curves, err := mngr.getPlotParamCurves(ctx)
 	if err != nil {
 		err := fmt.Errorf("manager Run error getting plotParamCurves: %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		_ = mngr.SetStatus("error")
 		return err
 	}
-	dateRange, err := mngr.getDateRange()
+	dateRange, err := mngr.getDateRange(ctx)
 	if err != nil {
 		err := fmt.Errorf("manager Run error getting daterange: %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+		_ = mngr.SetStatus("error")
+		return err
+	}
+	// all of the directors of all the runs of the process share one capped mysql connection pool
+	mngr.mysqlPool, err = globalMySQLPool(mysqlCredentials, mngr.config.MySQLMaxOpenConns, director.NewConnectionPool)
+	if err != nil {
+		err := fmt.Errorf("manager Run error getting mysql connection pool: %w", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		_ = mngr.SetStatus("error")
 		return err
 	}
-	numCurves := len(curves)
+	// the waits of this run, the pool is shared with the other runs
+	poolStatsAtStart := mngr.mysqlPool.Stats()
+	if mngr.config.ExplainMode != ExplainOff {
+		err = mngr.explainQueries(ctx, resultsBlocks, queryBlocks, plotParams, curves, dateRange)
+		if err != nil {
+			err := fmt.Errorf("manager Run error in the query cost pre-flight: %w", err)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+			_ = mngr.SetStatus("error")
+			return err
+		}
+	}
 	// blocks and queryBlocks have the same keys
 	numBlocks := len(blockKeys)
-	// create an errgroup for running all the block/regions in go routines
-	errGroup := new(errgroup.Group)
-	// don't really care what SINGLETHREADEDMANAGER env var is set to, just if it is set
-	_, singleThreadedManager := os.LookupEnv("SINGLETHREADEDMANGER")
-	if singleThreadedManager {
-		log.Print("manager is Running SINGLETHREADEDMANGER")
+	// the regions are collected first so that the progress has all their cells from the start
+	var regionRuns []regionRun
+	// a scope that has no cells is a mistake, e.g. a misspelled block, and the document is left as it is
+	err = mngr.scope.check(resultsBlocks)
+	if err != nil {
+		return fmt.Errorf("manager Run error: %w", err)
+	}
+	// a resumed run only processes the cells that the checkpoint of the document leaves to do
+	mngr.checkpoint, err = mngr.loadCheckpoint(ctx)
+	if err != nil {
+		err := fmt.Errorf("manager Run error: %w", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+		_ = mngr.SetStatus("error")
+		return err
+	}
+	// the thresholds that the results are computed with
+	err = mngr.upsertSubDocument(ctx, "thresholds", thresholds)
+	if err != nil {
+		err := fmt.Errorf("manager Run error upserting the thresholds: %w", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+		_ = mngr.SetStatus("error")
+		return err
 	}
+	// the progress of the run is written to the document while the regions are processed
+	tracker := newProgressTracker(start)
+	tracker.queryCache = mngr.queryCache
+	stopProgress := mngr.reportProgress(ctx, tracker)
+	defer stopProgress()
 	for i := 0; i < numBlocks; i++ {
 		blockName := blockKeys[i]
+		if !mngr.scope.selectsBlock(blockName) {
+			continue
+		}
 		block := resultsBlocks[blockName]
-		queryBlock := queryBlocks[blockKeys[i]].(map[string]interface{})
-		var appName string
-		for i := 0; i < numCurves; i++ {
-			curve := curves[i]
-			if curve["label"] == block.(map[string]interface{})["blockTitle"].(map[string]interface{})["label"] {
-				appName = curve["application"].(string)
-				break
-			}
+		queryBlock := queryBlocks[blockName]
+		if block == nil || queryBlock == nil {
+			err := fmt.Errorf("manager Run block %q has no results or no queryMap block", blockName)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+			_ = mngr.SetStatus("error")
+			return err
 		}
-		queryData := queryBlock["data"].(map[string]interface{})
-		blockRegionNames := getMapKeys(block.(map[string]interface{})["data"].(map[string]interface{}))
-		sort.Strings(blockRegionNames)
-		queryRegionNames := getMapKeys(queryData)
-		sort.Strings(queryRegionNames)
+		appName, templateVariables, err := blockCurve(curves, block.BlockTitle.Label)
+		if err != nil {
+			err := fmt.Errorf("manager Run block %q error: %w", blockName, err)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
+			_ = mngr.SetStatus("error")
+			return err
+		}
+		blockRegionNames := sortedKeys(block.Data)
+		queryRegionNames := sortedKeys(queryBlock.Data)
 		numBlockRegions := len(blockRegionNames)
 		numQueryRegions := len(queryRegionNames)
 		if numBlockRegions != numQueryRegions {
 			err := fmt.Errorf("manager Run Number of block regions %v does not equal the number of query regions %v", numBlockRegions, numQueryRegions)
-			_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 			_ = mngr.SetStatus("error")
 			return err
 		}
 		if !reflect.DeepEqual(blockRegionNames, queryRegionNames) {
 			err := fmt.Errorf("manager block regions list %v does not equal query regions list %v", blockRegionNames, queryRegionNames)
-			_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 			_ = mngr.SetStatus("error")
 			return err
 		}
 		for i := 0; i < numBlockRegions; i++ {
 			queryRegionName := queryRegionNames[i]
-			queryRegion := queryData[queryRegionName].(map[string]interface{})
+			queryRegion := queryBlock.Data[queryRegionName]
 			blockRegionName := blockRegionNames[i]
-			var region interface{}
-			regionPath := "results.blocks." + blockName + ".data." + blockRegionName
-			err = mngr.getSubDocument(regionPath, &region)
+			if !mngr.scope.selectsRegion(blockRegionName) {
+				continue
+			}
+			var region scorecard.ResultsRegion
+			regionPath := subDocPath("results", "blocks", blockName, "data", blockRegionName)
+			err = mngr.getSubDocument(ctx, regionPath, &region)
 			if err != nil {
 				err := fmt.Errorf("error getting region SubDocument %w", err)
-				_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+				_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 				_ = mngr.SetStatus("error")
 				return err
 			}
-			if !singleThreadedManager {
-				// process the region/block in the errgroup
-				errGroup.Go(func() error {
-					err = mngr.processRegion(
-						appName,
-						queryRegionName,
-						queryRegion,
-						blockRegionName,
-						&region,
-						regionPath,
-						mysqlCredentials,
-						dateRange,
-						minorThreshold,
-						majorThreshold,
-						scorecardAppUrl,
-						&cellCount)
-					return err
-				})
-			} else {
-				err = mngr.processRegion(
+			// the cells of the scope, or of the region, that the resume mode leaves to do
+			cells := mngr.scope.cells(region)
+			resumeRegion := region
+			if cells != nil {
+				if len(cells) == 0 {
+					continue
+				}
+				resumeRegion = region.Subset(cells)
+			}
+			if resumed := mngr.checkpoint.cellsToProcess(mngr.resumeMode, blockName, blockRegionName, resumeRegion); resumed != nil {
+				cells = resumed
+			}
+			cellCount := len(region.Keys())
+			if cells != nil {
+				if len(cells) == 0 {
+					// nothing to resume in this region
+					continue
+				}
+				cellCount = len(cells)
+			}
+			counter := tracker.addRegion(blockName, blockRegionName, cellCount)
+			regionRuns = append(regionRuns, regionRun{blockName: blockName, regionName: blockRegionName, run: func(ctx context.Context) error {
+				return mngr.processRegion(
+					ctx,
 					appName,
 					queryRegionName,
 					queryRegion,
+					blockName,
 					blockRegionName,
-					&region,
+					region,
+					cells,
 					regionPath,
-					mysqlCredentials,
 					dateRange,
-					minorThreshold,
-					majorThreshold,
+					thresholds.MinorPercent,
+					thresholds.MajorPercent,
+					templateVariables,
 					scorecardAppUrl,
-					&cellCount)
-				if err != nil {
-					// set error in the status field
-					err := fmt.Errorf("error processing scorecard single threaded Run %w", err)
-					// set error status in document
-					_ = mngr.SetStatus("error")
-					_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
-					return err
-				}
-			}
+					&summary,
+					counter)
+			}})
+		}
+	}
+	// process the block/regions in go routines and wait for all of them to complete
+	regionErrors, err := mngr.runRegions(ctx, regionRuns)
+	if err != nil {
+		err := fmt.Errorf("error processing scorecard Run %w", err)
+		if cause := context.Cause(ctx); cause != nil && !errors.Is(err, cause) {
+			// the regions return the error of their queries, the cause says why they were cancelled
+			err = fmt.Errorf("%w: %w", err, cause)
 		}
+		// set the error, cancelled or timeout status in the document
+		status := failedStatus(ctx)
+		_ = mngr.SetStatus(status)
+		_ = mngr.notifyStatus(scorecardAppUrl, status, err)
+		return err
 	}
-	if !singleThreadedManager {
-		// Wait for all processRegions to complete, capture their error values
-		err = errGroup.Wait()
+	if mngr.config.PartialSuccess {
+		// the list replaces the failed regions of an earlier run, it is empty if no region failed
+		err = mngr.upsertSubDocument(ctx, "regionErrors", regionErrors)
 		if err != nil {
-			// set error in the status field
-			err := fmt.Errorf("error processing scorecard multithreaded Run %w", err)
-			// set error status in document
+			err := fmt.Errorf("error upserting the region errors %w", err)
 			_ = mngr.SetStatus("error")
-			_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+			_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 			return err
 		}
 	}
+	stopProgress()
 	// set processedAt to now
 	err = mngr.SetProcessedAt()
 	if err != nil {
 		err := fmt.Errorf("error setting processedAt %w", err)
-		_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "error", err)
+		_ = mngr.notifyStatus(scorecardAppUrl, "error", err)
 		err = mngr.SetStatus("error")
 		return err
 	}
 	elapsed := time.Since(start)
-	log.Printf("This run processed: %v cells in %v", cellCount, elapsed)
-	_ = client.NotifyScorecardStatus(scorecardAppUrl, mngr.documentID, "ready", err)
+	cacheHits, cacheMisses := mngr.queryCache.Stats()
+	poolStats := mngr.mysqlPool.Stats()
+	log.Printf("This run processed: %v cells in %v - cell errors: %v - query cache hits: %v misses: %v evictions: %v - mysql connection waits: %v for %v",
+		summary.Cells(), elapsed, summary.String(), cacheHits, cacheMisses, mngr.queryCache.Evictions(),
+		poolStats.WaitCount-poolStatsAtStart.WaitCount, poolStats.WaitDuration-poolStatsAtStart.WaitDuration)
+	if len(regionErrors) > 0 {
+		// the other regions were processed, the document shows which regions failed and why
+		partialErr := &PartialError{Regions: regionErrors}
+		log.Print(partialErr)
+		_ = mngr.notifyStatus(scorecardAppUrl, "partial", partialErr)
+		_ = mngr.SetStatus("partial")
+		return partialErr
+	}
+	_ = mngr.notifyStatus(scorecardAppUrl, "ready", err)
 	// set status to ready
 	err = mngr.SetStatus("ready")
 	return nil
 }
 
-// SetStatus updates the couchbase scorecard document with the processing status
-func (mngr *Manager) SetStatus(status string) (err error) {
-	stmnt := "UPDATE vxdata._default.SCORECARD SET status = \"" + status + "\" where meta().id=\"" + mngr.documentID + "\";"
-	_, err = mngr.cb.Cluster.Query(stmnt, &gocb.QueryOptions{Adhoc: true})
-	if err != nil {
-		return err
-	}
-	return nil
+// SetStatus updates the scorecard document with the processing status
+func (mngr *Manager) SetStatus(status string) error {
+	return mngr.store.SetStatus(context.Background(), mngr.documentID, status)
 }
 
-// SetProcessedAt updates the couchbase scorecard document with the processed timestamp
-func (mngr *Manager) SetProcessedAt() (err error) {
-	timeStamp := strconv.FormatInt(time.Now().Unix(), 10)
-	stmnt := fmt.Sprintf("UPDATE vxdata._default.SCORECARD SET processedAt = %v where meta().id='%s';", timeStamp, mngr.documentID)
-	_, err = mngr.cb.Cluster.Query(stmnt, &gocb.QueryOptions{Adhoc: true})
-	if err != nil {
-		return err
-	}
-	return nil
+// SetProcessedAt updates the scorecard document with the processed timestamp
+func (mngr *Manager) SetProcessedAt() error {
+	return mngr.store.SetProcessedAt(context.Background(), mngr.documentID, time.Now())
 }
 
 // newScorecardManager creates a correctly initialized scorecard manager. GetManager should be used by clients instead of this.
 func newScorecardManager(documentID string) (*Manager, error) {
 	scMgr := Manager{}
-	scMgr.cb = &cbConnection{}
 	scMgr.documentID = documentID
+	scMgr.notifyScorecardApp = true
 	return &scMgr, nil
 }
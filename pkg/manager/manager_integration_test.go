@@ -5,16 +5,17 @@
 package manager
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
-	"sort"
 	"testing"
 	"time"
 
 	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
 	"github.com/couchbase/gocb/v2"
 	"github.com/joho/godotenv"
 	"go.uber.org/goleak"
@@ -39,7 +40,7 @@ func getTestDoc(mngr *Manager) (map[string]interface{}, error) {
 	loadEnvironmentFile()
 	// get the test scorecard document (this is a Result - not a document)
 	var scorecardDataIn *gocb.GetResult
-	scorecardDataIn, err := mngr.cb.Collection.Get("SCTEST:test_scorecard", nil)
+	scorecardDataIn, err := mngr.store.(*CouchbaseStore).collection.Get("SCTEST:test_scorecard", nil)
 	if err != nil {
 		return nil, fmt.Errorf("mysql_test_director error getting SCTEST:test_scorecard %w", err)
 	}
@@ -60,13 +61,13 @@ func upsertTestDoc(mngr *Manager, test_doc_file string, test_doc_id string) erro
 		return fmt.Errorf("upsertTestDoc error reading test scorecard file %w", err)
 	}
 	scorecardBytes, _ := os.ReadFile(testScorcardFile)
-	var scorecard map[string]interface{}
-	err := json.Unmarshal(scorecardBytes, &scorecard)
+	var doc map[string]interface{}
+	err := json.Unmarshal(scorecardBytes, &doc)
 	if err != nil {
 		return fmt.Errorf("upsertTestDoc error unmarshalling test scorecard file %w", err)
 	}
 	// upsert the test scorecard document
-	_, err = mngr.cb.Collection.Upsert(test_doc_id, scorecard, nil)
+	_, err = mngr.store.(*CouchbaseStore).collection.Upsert(test_doc_id, doc, nil)
 	if err != nil {
 		return fmt.Errorf("upsertTestDoc error upserting test scorecard file %w", err)
 	}
@@ -220,8 +221,6 @@ func Test_getQueryBlocks(t *testing.T) {
 		t.Fatal(fmt.Sprint("mysql_test_director error upserting test scorecard", err))
 	}
 
-	// these two (results and queryMap return map[string]interface{})
-	// (queryParams returns []interface{} - so it isn't here)
 	tests := []struct {
 		name    string
 		args    *Manager
@@ -237,15 +236,14 @@ func Test_getQueryBlocks(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		var retData map[string]interface{}
+		var retData map[string]*scorecard.QueryBlock
 		var err error
 		t.Run(tt.name, func(t *testing.T) {
-			retData, err = tt.args.getQueryBlocks()
+			retData, err = tt.args.getQueryBlocks(context.Background())
 			if retData == nil {
 				t.Errorf("%v error = %v", tt.name, err)
 			}
-			got := getMapKeys(retData)
-			sort.Strings(got)
+			got := sortedKeys(retData)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getQueryBlocks() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -263,7 +261,7 @@ func Test_getSliceResultBlocks(t *testing.T) {
 	documentID := "SCTEST:test_scorecard"
 	t.Setenv("PROC_TESTING_ACCEPT_SCTEST_DOCIDS", "")
 	// set these to make debugging easier
-	// t.Setenv("SINGLETHREADEDMANGER", "true")
+	// t.Setenv("SINGLETHREADEDMANAGER", "true")
 	// t.Setenv("SINGLETHREADEDDIRECTOR", "true")
 
 	var mngr *Manager
@@ -293,8 +291,6 @@ func Test_getSliceResultBlocks(t *testing.T) {
 		t.Fatal(fmt.Sprint("mysql_test_director error upserting test scorecard", err))
 	}
 
-	// these two (results and queryMap return map[string]interface{})
-	// (queryParams returns []interface{} - so it isn't here)
 	tests := []struct {
 		name    string
 		args    *Manager
@@ -310,15 +306,18 @@ func Test_getSliceResultBlocks(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		var retData []map[string]interface{}
+		var retData []scorecard.Curve
 		var err error
 		t.Run(tt.name, func(t *testing.T) {
-			retData, err = tt.args.getPlotParamCurves()
-			if retData == nil {
-				t.Errorf("%v error = %v", tt.name, err)
+			retData, err = tt.args.getPlotParamCurves(context.Background())
+			if len(retData) == 0 {
+				t.Fatalf("%v error = %v", tt.name, err)
+			}
+			params, err := retData[0].Parameters()
+			if err != nil {
+				t.Fatalf("%v error = %v", tt.name, err)
 			}
-			got := getMapKeys(retData[0])
-			sort.Strings(got)
+			got := sortedKeys(params)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getPlotParamCurves() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -334,7 +333,7 @@ func Test_runManager(t *testing.T) {
 	defer goleak.VerifyNone(t)
 	t.Setenv("PROC_TESTING_ACCEPT_SCTEST_DOCIDS", "")
 	// uncomment these for debugging
-	// t.Setenv("SINGLETHREADEDMANGER", "true")
+	// t.Setenv("SINGLETHREADEDMANAGER", "true")
 	// t.Setenv("SINGLETHREADEDDIRECTOR", "true")
 	var setupManager *Manager
 	var err error
@@ -476,7 +475,7 @@ func Test_runManager(t *testing.T) {
 		if err != nil {
 			t.Fatal(fmt.Errorf("manager test %s NewScorecardManager error getting a manager %w", tt.name, err))
 		}
-		err = manager.Run()
+		err = manager.Run(context.Background())
 		if err != nil {
 			t.Fatal(fmt.Errorf("manager test %s Run error %w", tt.name, err))
 		}
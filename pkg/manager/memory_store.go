@@ -0,0 +1,290 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a DocumentStore that holds its documents in memory as decoded JSON.
+// Values are copied in and out through JSON so that they look the same as they would in Couchbase.
+type MemoryStore struct {
+	mu   sync.Mutex
+	docs map[string]interface{}
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: map[string]interface{}{}}
+}
+
+// decodeJSON decodes data keeping numbers as json.Number, so that they are written back as they were read
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Put adds or replaces the document with the id
+func (store *MemoryStore) Put(documentID string, data []byte) error {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore Put %q error %w", documentID, err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return fmt.Errorf("manager MemoryStore Put %q error the document is not a JSON object", documentID)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.docs[documentID] = doc
+	return nil
+}
+
+// ReadFile puts the document in the file with the id
+func (store *MemoryStore) ReadFile(documentID, fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore ReadFile error %w", err)
+	}
+	return store.Put(documentID, data)
+}
+
+// WriteFile writes the document with the id to the file as indented JSON
+func (store *MemoryStore) WriteFile(documentID, fileName string) error {
+	store.mu.Lock()
+	doc, ok := store.docs[documentID]
+	var data []byte
+	var err error
+	if ok {
+		data, err = json.MarshalIndent(doc, "", "  ")
+	}
+	store.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("manager MemoryStore WriteFile %q error %w", documentID, ErrDocumentNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore WriteFile %q error %w", documentID, err)
+	}
+	err = os.WriteFile(fileName, append(data, '\n'), 0o600)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore WriteFile error %w", err)
+	}
+	return nil
+}
+
+// Close does nothing, the documents stay in the store
+func (store *MemoryStore) Close() error {
+	return nil
+}
+
+// GetDocument returns the JSON of the document
+func (store *MemoryStore) GetDocument(_ context.Context, documentID string) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	doc, ok := store.docs[documentID]
+	if !ok {
+		return nil, fmt.Errorf("manager MemoryStore GetDocument %q error %w", documentID, ErrDocumentNotFound)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("manager MemoryStore GetDocument %q error %w", documentID, err)
+	}
+	return data, nil
+}
+
+// GetSubDocument decodes the member at path into subDocPtr
+func (store *MemoryStore) GetSubDocument(_ context.Context, documentID, path string, subDocPtr interface{}) error {
+	elems, err := parseSubDocPath(path)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore GetSubDocument error %w", err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	value, ok := store.docs[documentID]
+	if !ok {
+		return fmt.Errorf("manager MemoryStore GetSubDocument %q error %w", documentID, ErrDocumentNotFound)
+	}
+	for _, elem := range elems {
+		value, ok = elem.child(value)
+		if !ok {
+			return fmt.Errorf("manager MemoryStore GetSubDocument %q error %w", path, ErrPathNotFound)
+		}
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, subDocPtr)
+	}
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore GetSubDocument getResult %q error %w", path, err)
+	}
+	return nil
+}
+
+// UpsertSubDocument sets the member at path to subDoc, like a Couchbase upsert it fails if the parent of the member doesn't exist
+func (store *MemoryStore) UpsertSubDocument(_ context.Context, documentID, path string, subDoc interface{}) error {
+	elems, err := parseSubDocPath(path)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore UpsertSubDocument error %w", err)
+	}
+	data, err := json.Marshal(subDoc)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore UpsertSubDocument %q error %w", path, err)
+	}
+	value, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("manager MemoryStore UpsertSubDocument %q error %w", path, err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	parent, ok := store.docs[documentID]
+	if !ok {
+		return fmt.Errorf("manager MemoryStore UpsertSubDocument %q error %w", documentID, ErrDocumentNotFound)
+	}
+	last := len(elems) - 1
+	for _, elem := range elems[:last] {
+		parent, ok = elem.child(parent)
+		if !ok {
+			return fmt.Errorf("manager MemoryStore UpsertSubDocument %q error %w", path, ErrPathNotFound)
+		}
+	}
+	if !elems[last].set(parent, value) {
+		return fmt.Errorf("manager MemoryStore UpsertSubDocument %q error %w", path, ErrPathNotFound)
+	}
+	return nil
+}
+
+// SetStatus sets the status member of the document
+func (store *MemoryStore) SetStatus(ctx context.Context, documentID, status string) error {
+	return store.UpsertSubDocument(ctx, documentID, "status", status)
+}
+
+// SetProcessedAt sets the processedAt member of the document to the epoch seconds of processedAt
+func (store *MemoryStore) SetProcessedAt(ctx context.Context, documentID string, processedAt time.Time) error {
+	return store.UpsertSubDocument(ctx, documentID, "processedAt", processedAt.Unix())
+}
+
+// pathElem is an element of a sub-document path, an object member or an array index
+type pathElem struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// child returns the member or the element of value that elem names
+func (elem pathElem) child(value interface{}) (interface{}, bool) {
+	if elem.isIndex {
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		i, ok := elem.arrayIndex(array)
+		if !ok {
+			return nil, false
+		}
+		return array[i], true
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	child, ok := obj[elem.name]
+	return child, ok
+}
+
+// set sets the member or the existing element of parent that elem names
+func (elem pathElem) set(parent, value interface{}) bool {
+	if elem.isIndex {
+		array, ok := parent.([]interface{})
+		if !ok {
+			return false
+		}
+		i, ok := elem.arrayIndex(array)
+		if ok {
+			array[i] = value
+		}
+		return ok
+	}
+	obj, ok := parent.(map[string]interface{})
+	if ok {
+		obj[elem.name] = value
+	}
+	return ok
+}
+
+// arrayIndex is the index of elem in array, -1 is the last element
+func (elem pathElem) arrayIndex(array []interface{}) (int, bool) {
+	i := elem.index
+	if i < 0 {
+		i += len(array)
+	}
+	return i, i >= 0 && i < len(array)
+}
+
+// parseSubDocPath splits a Couchbase sub-document path like
+// results.blocks.Block0.data.`All HRRR domain` or plotParams.curves[0].label into its elements.
+// A member name that has a dot or a bracket is quoted with backticks, a backtick in a quoted name is doubled.
+func parseSubDocPath(path string) ([]pathElem, error) {
+	var elems []pathElem
+	rest := path
+	for {
+		var name string
+		if strings.HasPrefix(rest, "`") {
+			// a quoted name ends at a backtick that isn't doubled
+			var b strings.Builder
+			i := 1
+			for {
+				end := strings.IndexByte(rest[i:], '`')
+				if end < 0 {
+					return nil, fmt.Errorf("sub-document path %q has an unterminated quote", path)
+				}
+				b.WriteString(rest[i : i+end])
+				i += end + 1
+				if !strings.HasPrefix(rest[i:], "`") {
+					break
+				}
+				b.WriteByte('`')
+				i++
+			}
+			name, rest = b.String(), rest[i:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name, rest = rest[:end], rest[end:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("sub-document path %q has an empty member name", path)
+		}
+		elems = append(elems, pathElem{name: name})
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("sub-document path %q has an unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("sub-document path %q has a bad index %q", path, rest[1:end])
+			}
+			elems = append(elems, pathElem{index: index, isIndex: true})
+			rest = rest[end+1:]
+		}
+		if rest == "" {
+			return elems, nil
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return nil, fmt.Errorf("sub-document path %q has %q after a member name", path, rest)
+		}
+		rest = rest[1:]
+	}
+}
@@ -0,0 +1,203 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func Test_parseSubDocPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathElem
+		wantErr bool
+	}{
+		{path: "dateRange", want: []pathElem{{name: "dateRange"}}},
+		{
+			path: "results.blocks.Block0.data.All HRRR domain",
+			want: []pathElem{{name: "results"}, {name: "blocks"}, {name: "Block0"}, {name: "data"}, {name: "All HRRR domain"}},
+		},
+		{
+			path: "plotParams.curves[0].label",
+			want: []pathElem{{name: "plotParams"}, {name: "curves"}, {index: 0, isIndex: true}, {name: "label"}},
+		},
+		{path: "a[1][-1]", want: []pathElem{{name: "a"}, {index: 1, isIndex: true}, {index: -1, isIndex: true}}},
+		{path: "a.`b.c[0]`.d", want: []pathElem{{name: "a"}, {name: "b.c[0]"}, {name: "d"}}},
+		{path: "`a``b`", want: []pathElem{{name: "a`b"}}},
+		{path: "", wantErr: true},
+		{path: "a..b", wantErr: true},
+		{path: "a.`b", wantErr: true},
+		{path: "a[x]", wantErr: true},
+		{path: "a[0", wantErr: true},
+		{path: "`a`b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseSubDocPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSubDocPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSubDocPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	err := store.Put("SC:doc", []byte(`{"status": "pending", "processedAt": 1700000000, "plotParams": {"curves": [{"label": "Block0"}, {"label": "Block1"}]}, "results": {"blocks": {"Block0": {"data": {}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		id      string
+		path    string
+		value   interface{}
+		wantErr error
+	}{
+		{name: "member", id: "SC:doc", path: "status", value: "ready"},
+		{name: "new member", id: "SC:doc", path: "results.blocks.Block0.data.All HRRR domain", value: map[string]interface{}{"RMSE": -9999.0}},
+		{name: "array element", id: "SC:doc", path: "plotParams.curves[-1].label", value: "Block2"},
+		{name: "missing parent", id: "SC:doc", path: "results.blocks.Block1.data", value: "x", wantErr: ErrPathNotFound},
+		{name: "index out of range", id: "SC:doc", path: "plotParams.curves[2]", value: "x", wantErr: ErrPathNotFound},
+		{name: "index of an object", id: "SC:doc", path: "plotParams[0]", value: "x", wantErr: ErrPathNotFound},
+		{name: "missing document", id: "SC:other", path: "status", value: "x", wantErr: ErrDocumentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.UpsertSubDocument(ctx, tt.id, tt.path, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpsertSubDocument() error = %v, want %v", err, tt.wantErr)
+			}
+			var got interface{}
+			err = store.GetSubDocument(ctx, tt.id, tt.path, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSubDocument() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("GetSubDocument() = %v, want %v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_files(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	err := store.ReadFile("SCTEST:test_Surface", "./testdata/test_Surface.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	processedAt := time.Unix(1700000000, 0)
+	if err := store.SetStatus(ctx, "SCTEST:test_Surface", "ready"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetProcessedAt(ctx, "SCTEST:test_Surface", processedAt); err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(t.TempDir(), "result.json")
+	if err := store.WriteFile("SCTEST:test_Surface", fileName); err != nil {
+		t.Fatal(err)
+	}
+	written := NewMemoryStore()
+	if err := written.ReadFile("SCTEST:test_Surface", fileName); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := store.GetDocument(ctx, "SCTEST:test_Surface")
+	got, err := written.GetDocument(ctx, "SCTEST:test_Surface")
+	if err != nil || string(got) != string(want) {
+		t.Fatalf("the written document differs from the stored document, error %v", err)
+	}
+	var status string
+	var gotProcessedAt int64
+	if err := written.GetSubDocument(ctx, "SCTEST:test_Surface", "status", &status); err != nil || status != "ready" {
+		t.Errorf("status = %q, error %v, want \"ready\"", status, err)
+	}
+	if err := written.GetSubDocument(ctx, "SCTEST:test_Surface", "processedAt", &gotProcessedAt); err != nil || gotProcessedAt != processedAt.Unix() {
+		t.Errorf("processedAt = %v, error %v, want %v", gotProcessedAt, err, processedAt.Unix())
+	}
+	if err := store.WriteFile("SCTEST:other", fileName); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("WriteFile() error = %v, want %v", err, ErrDocumentNotFound)
+	}
+}
+
+// a manager that is given a store doesn't need Couchbase
+func TestManager_memoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.ReadFile("SCTEST:test_Surface", "./testdata/test_Surface.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("SCTEST:invalid", []byte(`{"dateRange": "01/01/2023 00:00 - 01/02/2023 00:00"}`)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CB_HOST", "")
+	t.Setenv("PROC_TESTING_ACCEPT_SCTEST_DOCIDS", "")
+
+	mngr, err := GetManager("SCTEST:test_Surface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mngr.SetDocumentStore(store)
+	problems, err := mngr.Validate(ctx)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Validate() = %v, error %v, want no problems", problems, err)
+	}
+	report, err := mngr.DryRun(ctx)
+	if err != nil || report.ProblemCount() != 0 || report.Cells == 0 {
+		t.Errorf("DryRun() = %+v, error %v, want cells and no problems", report, err)
+	}
+	if err := mngr.SetStatus("error"); err != nil {
+		t.Fatal(err)
+	}
+	var status string
+	if err := store.GetSubDocument(ctx, "SCTEST:test_Surface", "status", &status); err != nil || status != "error" {
+		t.Errorf("status = %q, error %v, want \"error\"", status, err)
+	}
+
+	invalid, err := GetManager("SCTEST:invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid.SetDocumentStore(store)
+	if problems, err := invalid.Validate(ctx); err != nil || len(problems) == 0 {
+		t.Errorf("Validate() = %v, error %v, want problems", problems, err)
+	}
+	if _, err := invalid.getPlotParams(ctx); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("getPlotParams() error = %v, want %v", err, ErrPathNotFound)
+	}
+}
+
+// a run of an invalid document in a store sets its status without notifying the scorecard app
+func TestManager_Run_memoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Put("SC:invalid", []byte(`{"dateRange": "01/01/2023 00:00 - 01/02/2023 00:00", "status": "pending"}`)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CB_HOST", "")
+	t.Setenv("MYSQL_HOST", "localhost:1")
+	t.Setenv("MYSQL_USER", "user")
+	t.Setenv("MYSQL_PASSWORD", "password")
+	mngr, err := GetManager("SC:invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mngr.SetDocumentStore(store)
+	mngr.SetNotifyScorecardApp(false)
+	var invalid *scorecard.ValidationError
+	if err := mngr.Run(context.Background()); !errors.As(err, &invalid) {
+		t.Fatalf("Run() error = %v, want a validation error", err)
+	}
+	var status string
+	if err := store.GetSubDocument(context.Background(), "SC:invalid", "status", &status); err != nil || status != "error" {
+		t.Errorf("status = %q, error %v, want \"error\"", status, err)
+	}
+}
@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+)
+
+var (
+	mysqlPoolsLock sync.Mutex
+	mysqlPools     = map[director.DbCredentials]*director.ConnectionPool{}
+)
+
+// openPool opens a MySQL connection pool, the tests open pools without a server
+type openPool func(credentials director.DbCredentials, maxOpenConns int) (*director.ConnectionPool, error)
+
+// globalMySQLPool returns the MySQL connection pool that all the runs of the process share, so that
+// PROC_MYSQL_MAX_OPEN_CONNS caps the connections of the process and not those of each scorecard. Like the
+// director slots the pool is opened with maxOpenConns by the first run and the cap of later runs is ignored.
+// The pool stays open for the life of the process. A pool that fails to open isn't kept, the next run tries again.
+func globalMySQLPool(credentials director.DbCredentials, maxOpenConns int, open openPool) (*director.ConnectionPool, error) {
+	mysqlPoolsLock.Lock()
+	defer mysqlPoolsLock.Unlock()
+	if pool, ok := mysqlPools[credentials]; ok {
+		return pool, nil
+	}
+	pool, err := open(credentials, maxOpenConns)
+	if err != nil {
+		return nil, fmt.Errorf("manager globalMySQLPool error: %w", err)
+	}
+	mysqlPools[credentials] = pool
+	return pool, nil
+}
@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+)
+
+func Test_globalMySQLPool(t *testing.T) {
+	credentials := director.DbCredentials{User: "Test_globalMySQLPool", Host: "localhost:3306"}
+	var opened []int
+	fail := true
+	open := func(_ director.DbCredentials, maxOpenConns int) (*director.ConnectionPool, error) {
+		opened = append(opened, maxOpenConns)
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &director.ConnectionPool{}, nil
+	}
+	if _, err := globalMySQLPool(credentials, 10, open); err == nil {
+		t.Fatal("globalMySQLPool() expected the error of the pool")
+	}
+	// the failure isn't kept, the next run opens the pool and the runs after it share it
+	fail = false
+	first, err := globalMySQLPool(credentials, 10, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := globalMySQLPool(credentials, 30, open)
+	if err != nil || second != first {
+		t.Errorf("globalMySQLPool() = %p, %v, want the pool of the first run %p", second, err, first)
+	}
+	if len(opened) != 2 || opened[1] != 10 {
+		t.Errorf("globalMySQLPool() opened pools with %v connections, want [10 10]", opened)
+	}
+	other, err := globalMySQLPool(director.DbCredentials{User: "Test_globalMySQLPool", Host: "other:3306"}, 10, open)
+	if err != nil || other == first {
+		t.Errorf("globalMySQLPool() of another server = %p, %v, want its own pool", other, err)
+	}
+}
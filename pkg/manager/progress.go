@@ -0,0 +1,194 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// Progress is the progress of a run. It is written to the progress member of the scorecard document
+// while the document is processed and the API returns it with the job. The progress that is written
+// at the end of the run is its summary.
+type Progress struct {
+	Percent      float64 `json:"percent"`       // of the cells that are done, 0 to 100
+	Cells        int64   `json:"cells"`         // the cells that the run processes
+	CellsDone    int64   `json:"cells_done"`    // including the cells that errored
+	CellsErrored int64   `json:"cells_errored"` // cells whose value is the error value, e.g. no data
+	// the cells that errored by the class of their error, e.g. no_data or syntax
+	ErrorClasses map[director.ErrorClass]int64 `json:"error_classes,omitempty"`
+	StartedAt    int64                         `json:"started_at"`    // epoch seconds
+	UpdatedAt    int64                         `json:"updated_at"`    // epoch seconds
+	ETA          int64                         `json:"eta,omitempty"` // the estimated end of the run in epoch seconds, once some cells are done
+	// the queries of the run that were answered by the query cache (hits) and that went to the database (misses)
+	QueryCacheHits   int64 `json:"query_cache_hits"`
+	QueryCacheMisses int64 `json:"query_cache_misses"`
+	// the progress of each region of each block
+	Blocks map[string]map[string]RegionProgress `json:"blocks"`
+}
+
+// RegionProgress is the progress of a region of a block
+type RegionProgress struct {
+	Cells        int64 `json:"cells"`
+	CellsDone    int64 `json:"cells_done"`
+	CellsErrored int64 `json:"cells_errored"`
+	// the class of the error of each cell that errored, by its keys (see scorecard.CellKeys.String)
+	FailedCells map[string]director.ErrorClass `json:"failed_cells,omitempty"`
+}
+
+// progressTracker counts the cells of a run as the directors finish them
+type progressTracker struct {
+	start      time.Time
+	queryCache *director.QueryCache // of the run, nil if its stats aren't part of the progress
+	lock       sync.Mutex           // for regions, the counters of a region are atomic
+	regions    map[string]map[string]*regionCounter
+}
+
+// regionCounter is the director.CellObserver of a region, it counts the cells for the progress
+// and keeps the cells that failed for the checkpoint
+type regionCounter struct {
+	cells   int64
+	done    atomic.Int64
+	errored atomic.Int64
+	lock    sync.Mutex
+	errors  map[string]director.ErrorClass // by scorecard.CellKeys.String
+}
+
+func (r *regionCounter) CellDone(keys scorecard.CellKeys, errorClass director.ErrorClass) {
+	r.done.Add(1)
+	if errorClass == "" {
+		return
+	}
+	r.errored.Add(1)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.errors == nil {
+		r.errors = map[string]director.ErrorClass{}
+	}
+	r.errors[keys.String()] = errorClass
+}
+
+// cellErrors returns the cells that failed so far and why
+func (r *regionCounter) cellErrors() map[string]director.ErrorClass {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	cellErrors := make(map[string]director.ErrorClass, len(r.errors))
+	for key, class := range r.errors {
+		cellErrors[key] = class
+	}
+	return cellErrors
+}
+
+func newProgressTracker(start time.Time) *progressTracker {
+	return &progressTracker{start: start, regions: map[string]map[string]*regionCounter{}}
+}
+
+// addRegion adds the cells of a region to the run and returns the observer for its director
+func (t *progressTracker) addRegion(blockName, regionName string, cells int) *regionCounter {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.regions[blockName] == nil {
+		t.regions[blockName] = map[string]*regionCounter{}
+	}
+	counter := &regionCounter{cells: int64(cells)}
+	t.regions[blockName][regionName] = counter
+	return counter
+}
+
+// progress returns the progress at now
+func (t *progressTracker) progress(now time.Time) Progress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := Progress{
+		StartedAt: t.start.Unix(),
+		UpdatedAt: now.Unix(),
+		Blocks:    make(map[string]map[string]RegionProgress, len(t.regions)),
+	}
+	for blockName, regions := range t.regions {
+		p.Blocks[blockName] = make(map[string]RegionProgress, len(regions))
+		for regionName, counter := range regions {
+			region := RegionProgress{Cells: counter.cells, CellsDone: counter.done.Load(), CellsErrored: counter.errored.Load()}
+			if region.CellsErrored > 0 {
+				region.FailedCells = counter.cellErrors()
+				if p.ErrorClasses == nil {
+					p.ErrorClasses = map[director.ErrorClass]int64{}
+				}
+				for _, class := range region.FailedCells {
+					p.ErrorClasses[class]++
+				}
+			}
+			p.Blocks[blockName][regionName] = region
+			p.Cells += region.Cells
+			p.CellsDone += region.CellsDone
+			p.CellsErrored += region.CellsErrored
+		}
+	}
+	if t.queryCache != nil {
+		p.QueryCacheHits, p.QueryCacheMisses = t.queryCache.Stats()
+	}
+	if p.Cells > 0 {
+		p.Percent = float64(p.CellsDone) * 100 / float64(p.Cells)
+	}
+	if p.CellsDone > 0 && p.CellsDone < p.Cells {
+		// the remaining cells take as long as the cells that are done did, on average
+		elapsed := now.Sub(t.start)
+		remaining := time.Duration(float64(elapsed) / float64(p.CellsDone) * float64(p.Cells-p.CellsDone))
+		p.ETA = now.Add(remaining).Unix()
+	}
+	return p
+}
+
+// SetProgressListener sets a function that is called with the progress of a run each time it is written to the
+// document. It is called from another goroutine, but never after Run returns.
+func (mngr *Manager) SetProgressListener(listener func(Progress)) {
+	mngr.progressListener = listener
+}
+
+// reportProgress writes the progress of the run to the document every PROC_PROGRESS_INTERVAL, if cells were
+// finished since it was last written. The returned function writes the final progress and stops the reports,
+// it can be called more than once.
+func (mngr *Manager) reportProgress(ctx context.Context, tracker *progressTracker) (stop func()) {
+	// the final progress is written even if the run was cancelled
+	ctx = context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	written := int64(-1) // the cells done that were last written, only used by one goroutine at a time
+	write := func(final bool) {
+		p := tracker.progress(time.Now())
+		if p.CellsDone == written && !final {
+			return
+		}
+		written = p.CellsDone
+		if err := mngr.upsertSubDocument(ctx, "progress", p); err != nil {
+			log.Printf("manager error writing the progress of %s: %v", mngr.documentID, err)
+		}
+		if mngr.progressListener != nil {
+			mngr.progressListener(p)
+		}
+	}
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(mngr.config.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				write(false)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			write(true)
+		})
+	}
+}
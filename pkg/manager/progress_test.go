@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func Test_progressTracker(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := newProgressTracker(start)
+	tracker.queryCache = director.NewQueryCache()
+	west := tracker.addRegion("Block0", "Western HRRR domain", 4)
+	east := tracker.addRegion("Block0", "Eastern HRRR domain", 4)
+	tracker.addRegion("Block1", "All HRRR domain", 2)
+	cell := scorecard.CellKeys{Statistic: "RMSE", Variable: "2m temperature", Threshold: "threshold_NA", Level: "level_NA"}
+	for _, fcst := range []string{"0", "3", "6", "9"} {
+		cell.ForecastLength = fcst
+		if fcst == "3" {
+			west.CellDone(cell, director.ErrorClassNoData)
+		} else if fcst == "0" {
+			west.CellDone(cell, "")
+		}
+		if fcst != "9" {
+			east.CellDone(cell, "")
+		}
+	}
+
+	got := tracker.progress(start.Add(50 * time.Second))
+	want := Progress{
+		Percent:      50,
+		Cells:        10,
+		CellsDone:    5,
+		CellsErrored: 1,
+		ErrorClasses: map[director.ErrorClass]int64{director.ErrorClassNoData: 1},
+		StartedAt:    1700000000,
+		UpdatedAt:    1700000050,
+		ETA:          1700000100, // 5 cells took 50 seconds, 5 more cells take another 50 seconds
+		Blocks: map[string]map[string]RegionProgress{
+			"Block0": {
+				"Western HRRR domain": {Cells: 4, CellsDone: 2, CellsErrored: 1, FailedCells: map[string]director.ErrorClass{
+					"RMSE -> 2m temperature -> threshold_NA -> level_NA -> 3": director.ErrorClassNoData,
+				}},
+				"Eastern HRRR domain": {Cells: 4, CellsDone: 3},
+			},
+			"Block1": {"All HRRR domain": {Cells: 2}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress() = %+v, want %+v", got, want)
+	}
+	wantErrors := map[string]director.ErrorClass{"RMSE -> 2m temperature -> threshold_NA -> level_NA -> 3": director.ErrorClassNoData}
+	if got := west.cellErrors(); !reflect.DeepEqual(got, wantErrors) {
+		t.Errorf("cellErrors() = %v, want %v", got, wantErrors)
+	}
+}
+
+func TestManager_reportProgress(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Put("SC:doc", []byte(`{"status": "pending"}`)); err != nil {
+		t.Fatal(err)
+	}
+	mngr := &Manager{documentID: "SC:doc", store: store, config: Config{ProgressInterval: time.Millisecond}}
+	var lock sync.Mutex
+	var reported []Progress
+	mngr.SetProgressListener(func(p Progress) {
+		lock.Lock()
+		defer lock.Unlock()
+		reported = append(reported, p)
+	})
+	tracker := newProgressTracker(time.Now())
+	region := tracker.addRegion("Block0", "All HRRR domain", 2)
+	stop := mngr.reportProgress(ctx, tracker)
+	region.CellDone(scorecard.CellKeys{ForecastLength: "3"}, "")
+	time.Sleep(20 * time.Millisecond)
+	region.CellDone(scorecard.CellKeys{ForecastLength: "6"}, "")
+	stop()
+	stop()
+
+	lock.Lock()
+	n := len(reported)
+	lock.Unlock()
+	// the cell that was done first was reported, then nothing until the final report
+	if n < 2 || n > 3 || reported[n-1].CellsDone != 2 || reported[n-1].Percent != 100 {
+		t.Fatalf("reportProgress() reported %+v, want a report of the first cell and the final report", reported)
+	}
+	var written Progress
+	if err := store.GetSubDocument(ctx, "SC:doc", "progress", &written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, reported[n-1]) {
+		t.Errorf("the document has the progress %+v, want %+v", written, reported[n-1])
+	}
+}
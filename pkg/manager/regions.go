@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// regionRun processes a region of a block with a director
+type regionRun struct {
+	blockName  string
+	regionName string
+	run        func(ctx context.Context) error
+}
+
+// RegionError is a region that failed in a run with PROC_PARTIAL_SUCCESS. The errors of the regions are
+// written to the regionErrors member of the document.
+type RegionError struct {
+	Block  string `json:"block"`
+	Region string `json:"region"`
+	Error  string `json:"error"`
+}
+
+// PartialError is returned by Run when some regions failed and the others were processed, see PROC_PARTIAL_SUCCESS
+type PartialError struct {
+	Regions []RegionError
+}
+
+func (e *PartialError) Error() string {
+	regions := make([]string, len(e.Regions))
+	for i, region := range e.Regions {
+		regions[i] = fmt.Sprintf("%s %s: %s", region.Block, region.Region, region.Error)
+	}
+	return fmt.Sprintf("manager Run error %d regions failed: %s", len(e.Regions), strings.Join(regions, "; "))
+}
+
+// PartialErrors returns the failed regions, for the API job
+func (e *PartialError) PartialErrors() any {
+	return e.Regions
+}
+
+// failedStatus returns the status of a run whose regions stopped with an error: "cancelled" when ctx was cancelled
+// (e.g. DELETE /jobs/:id or an interrupt of the cli), "timeout" when its deadline passed (PROC_SCORECARD_TIMEOUT)
+// and "error" when a region failed by itself
+func failedStatus(ctx context.Context) string {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "cancelled"
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// runRegions processes the regions, at most PROC_MAX_DIRECTORS at a time and no more than PROC_GLOBAL_MAX_DIRECTORS
+// with the other runs of the process. The first region that fails stops the others, unless PROC_PARTIAL_SUCCESS
+// is set. Then the other regions are processed and the failed ones are returned, in block and region order,
+// but the run still stops when ctx is done.
+func (mngr *Manager) runRegions(ctx context.Context, regionRuns []regionRun) ([]RegionError, error) {
+	errGroup, groupCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(mngr.config.MaxDirectors)
+	slots := globalDirectorSlots(mngr.config.GlobalMaxDirectors)
+	var lock sync.Mutex
+	regionErrors := []RegionError{}
+	for _, regionRun := range regionRuns {
+		errGroup.Go(func() error {
+			err := withDirectorSlot(groupCtx, slots, regionRun.run)
+			if err == nil || !mngr.config.PartialSuccess || ctx.Err() != nil {
+				return err
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			regionErrors = append(regionErrors, RegionError{Block: regionRun.blockName, Region: regionRun.regionName, Error: err.Error()})
+			return nil
+		})
+	}
+	if err := errGroup.Wait(); err != nil {
+		return nil, err
+	}
+	sort.Slice(regionErrors, func(i, j int) bool {
+		if regionErrors[i].Block != regionErrors[j].Block {
+			return regionErrors[i].Block < regionErrors[j].Block
+		}
+		return regionErrors[i].Region < regionErrors[j].Region
+	})
+	return regionErrors, nil
+}
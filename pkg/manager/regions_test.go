@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestManager_runRegions(t *testing.T) {
+	failing := map[string]bool{"Block0/Western HRRR domain": true, "Block1/All HRRR domain": true}
+	newRegionRuns := func(processed *atomic.Int64) []regionRun {
+		var regionRuns []regionRun
+		for _, blockName := range []string{"Block1", "Block0"} {
+			for _, regionName := range []string{"Western HRRR domain", "All HRRR domain", "Eastern HRRR domain"} {
+				regionRuns = append(regionRuns, regionRun{blockName: blockName, regionName: regionName, run: func(ctx context.Context) error {
+					if failing[blockName+"/"+regionName] {
+						return fmt.Errorf("no such table")
+					}
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					processed.Add(1)
+					return nil
+				}})
+			}
+		}
+		return regionRuns
+	}
+	tests := []struct {
+		name          string
+		partial       bool
+		want          []RegionError
+		wantErr       bool
+		wantProcessed int64
+	}{
+		{
+			name:    "partial success",
+			partial: true,
+			want: []RegionError{
+				{Block: "Block0", Region: "Western HRRR domain", Error: "no such table"},
+				{Block: "Block1", Region: "All HRRR domain", Error: "no such table"},
+			},
+			wantProcessed: 4,
+		},
+		{name: "the first error stops the run", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mngr := &Manager{config: Config{MaxDirectors: 1, GlobalMaxDirectors: 4, PartialSuccess: tt.partial}}
+			var processed atomic.Int64
+			got, err := mngr.runRegions(context.Background(), newRegionRuns(&processed))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runRegions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runRegions() = %v, want %v", got, tt.want)
+			}
+			if tt.wantProcessed != 0 && processed.Load() != tt.wantProcessed {
+				t.Errorf("runRegions() processed %v regions, want %v", processed.Load(), tt.wantProcessed)
+			}
+		})
+	}
+
+	// a cancelled run fails even with partial success
+	mngr := &Manager{config: Config{MaxDirectors: 2, GlobalMaxDirectors: 4, PartialSuccess: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var processed atomic.Int64
+	if _, err := mngr.runRegions(ctx, newRegionRuns(&processed)); !errors.Is(err, context.Canceled) {
+		t.Errorf("runRegions() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPartialError(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &PartialError{Regions: []RegionError{{Block: "Block0", Region: "All HRRR domain", Error: "no such table"}}})
+	var partial *PartialError
+	if !errors.As(err, &partial) || len(partial.PartialErrors().([]RegionError)) != 1 {
+		t.Fatalf("errors.As() didn't find the PartialError in %v", err)
+	}
+	want := "manager Run error 1 regions failed: Block0 All HRRR domain: no such table"
+	if partial.Error() != want {
+		t.Errorf("Error() = %q, want %q", partial.Error(), want)
+	}
+}
+
+func Test_failedStatus(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timedOut, cancelTimeout := context.WithTimeoutCause(context.Background(), 0, errors.New("manager Run scorecard timeout of 0s exceeded"))
+	defer cancelTimeout()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "a region failed", ctx: context.Background(), want: "error"},
+		{name: "cancelled", ctx: cancelled, want: "cancelled"},
+		{name: "scorecard timeout", ctx: timedOut, want: "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failedStatus(tt.ctx); got != tt.want {
+				t.Errorf("failedStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// cellPathSeparator separates the keys of a cell path, like builder GetPath
+const cellPathSeparator = " -> "
+
+// Scope selects the part of a document that Run processes, e.g. to recompute a block after one of its
+// query templates was fixed. Only the results of the scope are written to the document. The zero Scope
+// is the whole document.
+type Scope struct {
+	Block  string // the results block, empty is every block
+	Region string // the region of the blocks, empty is every region
+	// the keychain of a cell or of the cells below it in the builder GetPath format, starting with the region
+	// e.g. "All HRRR domain -> RMSE -> 2m temperature", empty is every cell of the regions
+	Cell string
+	keys []string // the keys of Cell below the region
+}
+
+// ParseScope returns the Scope of a block, a region and a cell path, any of them may be empty.
+// The region of the cell path must be the region, if both are given.
+func ParseScope(block, region, cell string) (Scope, error) {
+	scope := Scope{Block: block, Region: region, Cell: cell}
+	if cell == "" {
+		return scope, nil
+	}
+	keys := strings.Split(cell, cellPathSeparator)
+	if len(keys) > 6 {
+		return Scope{}, fmt.Errorf("manager scope error the cell path %q has more than 6 keys", cell)
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimSpace(key)
+		if keys[i] == "" {
+			return Scope{}, fmt.Errorf("manager scope error the cell path %q has an empty key", cell)
+		}
+	}
+	if region != "" && keys[0] != region {
+		return Scope{}, fmt.Errorf("manager scope error the cell path %q isn't in the region %q", cell, region)
+	}
+	scope.Region = keys[0]
+	scope.keys = keys[1:]
+	return scope, nil
+}
+
+// SetScope selects the part of the document that Run processes, see ParseScope
+func (mngr *Manager) SetScope(scope Scope) {
+	mngr.scope = scope
+}
+
+// isWhole reports whether the scope is the whole document
+func (s Scope) isWhole() bool {
+	return s.Block == "" && s.Region == "" && s.Cell == ""
+}
+
+// String describes the scope for messages
+func (s Scope) String() string {
+	switch {
+	case s.Cell != "":
+		return fmt.Sprintf("block %q cells %q", s.Block, s.Cell)
+	case s.Region != "":
+		return fmt.Sprintf("block %q region %q", s.Block, s.Region)
+	case s.Block != "":
+		return fmt.Sprintf("block %q", s.Block)
+	default:
+		return "the whole document"
+	}
+}
+
+func (s Scope) selectsBlock(name string) bool {
+	return s.Block == "" || s.Block == name
+}
+
+func (s Scope) selectsRegion(name string) bool {
+	return s.Region == "" || s.Region == name
+}
+
+// cells returns the cells of a region that the scope selects, nil means all of them
+func (s Scope) cells(region scorecard.ResultsRegion) []scorecard.CellKeys {
+	if len(s.keys) == 0 {
+		return nil
+	}
+	cells := []scorecard.CellKeys{}
+	for _, k := range region.Keys() {
+		keys := k.Keychain("")[1:]
+		selected := true
+		for i, key := range s.keys {
+			if keys[i] != key {
+				selected = false
+				break
+			}
+		}
+		if selected {
+			cells = append(cells, k)
+		}
+	}
+	return cells
+}
+
+// check returns an error if the scope doesn't select any cell of the blocks
+func (s Scope) check(blocks map[string]*scorecard.ResultsBlock) error {
+	for blockName, block := range blocks {
+		if block == nil || !s.selectsBlock(blockName) {
+			continue
+		}
+		for regionName, region := range block.Data {
+			if !s.selectsRegion(regionName) {
+				continue
+			}
+			if cells := s.cells(region); cells == nil || len(cells) > 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("manager scope error the document has no cells in %v", s)
+}
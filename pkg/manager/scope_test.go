@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		block   string
+		region  string
+		cell    string
+		want    Scope
+		wantErr bool
+	}{
+		{name: "whole document", want: Scope{}},
+		{name: "block", block: "Block0", want: Scope{Block: "Block0"}},
+		{name: "region", region: "All HRRR domain", want: Scope{Region: "All HRRR domain"}},
+		{
+			name:  "subtree",
+			block: "Block0",
+			cell:  "All HRRR domain -> RMSE -> 2m temperature",
+			want:  Scope{Block: "Block0", Region: "All HRRR domain", Cell: "All HRRR domain -> RMSE -> 2m temperature", keys: []string{"RMSE", "2m temperature"}},
+		},
+		{
+			name:   "cell",
+			region: "All HRRR domain",
+			cell:   "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6",
+			want: Scope{
+				Region: "All HRRR domain", Cell: "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6",
+				keys: []string{"RMSE", "2m temperature", "threshold_NA", "level_NA", "6"},
+			},
+		},
+		{name: "another region", region: "Eastern HRRR domain", cell: "All HRRR domain -> RMSE", wantErr: true},
+		{name: "empty key", cell: "All HRRR domain ->  -> 2m temperature", wantErr: true},
+		{name: "too many keys", cell: "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6 -> 7", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScope(tt.block, tt.region, tt.cell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScope() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScope_cells(t *testing.T) {
+	cell := func(statistic, fcst string) scorecard.CellKeys {
+		return scorecard.CellKeys{Statistic: statistic, Variable: "2m temperature", Threshold: "threshold_NA", Level: "level_NA", ForecastLength: fcst}
+	}
+	region := scorecard.ResultsRegion{}
+	for _, k := range []scorecard.CellKeys{cell("RMSE", "3"), cell("RMSE", "6"), cell("Bias", "3")} {
+		region.Set(k, -9999.0)
+	}
+	blocks := map[string]*scorecard.ResultsBlock{
+		"Block0": {Data: map[string]scorecard.ResultsRegion{"All HRRR domain": region}},
+		"Block1": nil,
+	}
+	tests := []struct {
+		name      string
+		block     string
+		region    string
+		cell      string
+		want      []scorecard.CellKeys
+		wantCheck bool
+	}{
+		{name: "whole document", want: nil, wantCheck: true},
+		{name: "region", region: "All HRRR domain", want: nil, wantCheck: true},
+		{name: "statistic", cell: "All HRRR domain -> RMSE", want: []scorecard.CellKeys{cell("RMSE", "3"), cell("RMSE", "6")}, wantCheck: true},
+		{name: "cell", cell: "All HRRR domain -> Bias -> 2m temperature -> threshold_NA -> level_NA -> 3", want: []scorecard.CellKeys{cell("Bias", "3")}, wantCheck: true},
+		{name: "missing cell", cell: "All HRRR domain -> MAE", want: []scorecard.CellKeys{}},
+		{name: "missing block", block: "Block2", want: nil},
+		{name: "missing region", region: "Eastern HRRR domain", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := ParseScope(tt.block, tt.region, tt.cell)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := scope.cells(region); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cells() = %v, want %v", got, tt.want)
+			}
+			if err := scope.check(blocks); (err == nil) != tt.wantCheck {
+				t.Errorf("check() error = %v, want an error %v", err, !tt.wantCheck)
+			}
+		})
+	}
+}
+
+func Test_subDocPath(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{names: []string{"results", "blocks", "Block0", "data", "All HRRR domain"}, want: "results.blocks.Block0.data.All HRRR domain"},
+		{names: []string{"RMSE", "2m temperature", "0.5", "level[1]"}, want: "RMSE.2m temperature.`0.5`.`level[1]`"},
+		{names: []string{"a`b"}, want: "`a``b`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := subDocPath(tt.names...)
+			if got != tt.want {
+				t.Errorf("subDocPath() = %q, want %q", got, tt.want)
+			}
+			elems, err := parseSubDocPath(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, elem := range elems {
+				if elem.name != tt.names[i] {
+					t.Errorf("the path has the name %q, want %q", elem.name, tt.names[i])
+				}
+			}
+		})
+	}
+}
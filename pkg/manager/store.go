@@ -0,0 +1,203 @@
+package manager
+
+/*
+A DocumentStore is where the manager reads scorecard documents and writes their results and status.
+The documents of the scorecard app are in Couchbase (CouchbaseStore). A MemoryStore holds documents
+in memory, it is used by the tests and it reads and writes JSON files for local runs.
+
+Paths are Couchbase sub-document paths, e.g. results.blocks.Block0.data.`All HRRR domain`
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/retry"
+	"github.com/couchbase/gocb/v2"
+)
+
+// DocumentStore reads and updates scorecard documents by id
+type DocumentStore interface {
+	// GetDocument returns the JSON of the whole document
+	GetDocument(ctx context.Context, documentID string) ([]byte, error)
+	// GetSubDocument decodes the member at path into subDocPtr
+	GetSubDocument(ctx context.Context, documentID, path string, subDocPtr interface{}) error
+	// UpsertSubDocument sets the member at path to subDoc, the parent of the member must exist
+	UpsertSubDocument(ctx context.Context, documentID, path string, subDoc interface{}) error
+	// SetStatus sets the processing status of the document e.g. "ready" or "error"
+	SetStatus(ctx context.Context, documentID, status string) error
+	// SetProcessedAt sets the time that the document was processed, in epoch seconds
+	SetProcessedAt(ctx context.Context, documentID string, processedAt time.Time) error
+	Close() error
+}
+
+var (
+	// ErrDocumentNotFound is returned by a MemoryStore for a document id that it doesn't have
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrPathNotFound is returned for a path that isn't in the document, by a MemoryStore and
+	// by GetSubDocument of a CouchbaseStore
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// subDocPath joins the names of the members into a sub-document path. A name that has a character
+// of the path syntax, e.g. a threshold like 0.5, is quoted with backticks.
+func subDocPath(names ...string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		if strings.ContainsAny(name, ".[]`") {
+			name = "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+		quoted[i] = name
+	}
+	return strings.Join(quoted, ".")
+}
+
+// CouchbaseStore is a DocumentStore for a Couchbase collection, its operations are retried after transient failures
+type CouchbaseStore struct {
+	cluster     *gocb.Cluster
+	bucket      *gocb.Bucket
+	scope       *gocb.Scope
+	collection  *gocb.Collection
+	retryPolicy retry.Policy
+}
+
+// NewCouchbaseStore connects to the Couchbase cluster and to the bucket, scope and collection of the credentials.
+// Make sure to call Close() on the returned store.
+func NewCouchbaseStore(cbCredentials director.DbCredentials, retryPolicy retry.Policy) (*CouchbaseStore, error) {
+	options := gocb.ClusterOptions{
+		Authenticator: gocb.PasswordAuthenticator{
+			Username: cbCredentials.User,
+			Password: cbCredentials.Password,
+		},
+	}
+	if err := options.ApplyProfile(gocb.ClusterConfigProfileWanDevelopment); err != nil {
+		return nil, fmt.Errorf("manager gocb ApplyProfile error: %w", err)
+	}
+	// Initialize the Connection
+	cluster, err := gocb.Connect("couchbase://"+cbCredentials.Host, options)
+	if err != nil {
+		return nil, fmt.Errorf("manager gocb Connect error: %w", err)
+	}
+	store := &CouchbaseStore{cluster: cluster, retryPolicy: retryPolicy}
+	store.bucket = cluster.Bucket(cbCredentials.Bucket)
+	err = store.bucket.WaitUntilReady(50*time.Second, nil)
+	if err != nil {
+		_ = cluster.Close(nil)
+		return nil, fmt.Errorf("manager bucket.WaitUntilReady error: %w", err)
+	}
+	store.scope = store.bucket.Scope(cbCredentials.Scope)
+	store.collection = store.scope.Collection(cbCredentials.Collection)
+	return store, nil
+}
+
+// Close closes the Couchbase connection
+func (store *CouchbaseStore) Close() error {
+	return store.cluster.Close(nil)
+}
+
+// isTransientCouchbaseError reports whether a failed Couchbase operation is worth running again
+func isTransientCouchbaseError(err error) bool {
+	return errors.Is(err, gocb.ErrTimeout) ||
+		errors.Is(err, gocb.ErrAmbiguousTimeout) ||
+		errors.Is(err, gocb.ErrUnambiguousTimeout) ||
+		errors.Is(err, gocb.ErrTemporaryFailure) ||
+		errors.Is(err, gocb.ErrServiceNotAvailable) ||
+		errors.Is(err, gocb.ErrOverload) ||
+		errors.Is(err, gocb.ErrDocumentLocked)
+}
+
+// GetDocument retrieves the whole document
+func (store *CouchbaseStore) GetDocument(ctx context.Context, documentID string) ([]byte, error) {
+	getResult, err := retry.Value(ctx, store.retryPolicy, "couchbase_get", isTransientCouchbaseError,
+		func(ctx context.Context) (*gocb.GetResult, error) {
+			return store.collection.Get(documentID, &gocb.GetOptions{Context: ctx})
+		})
+	if err != nil {
+		return nil, fmt.Errorf("manager CouchbaseStore GetDocument Get error %w", err)
+	}
+	var doc json.RawMessage
+	err = getResult.Content(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("manager CouchbaseStore GetDocument Content error %w", err)
+	}
+	return doc, nil
+}
+
+// GetSubDocument retrieves a Couchbase subdocument into subDocPtr
+func (store *CouchbaseStore) GetSubDocument(ctx context.Context, documentID, path string, subDocPtr interface{}) error {
+	ops := []gocb.LookupInSpec{
+		gocb.GetSpec(path, &gocb.GetSpecOptions{IsXattr: false}),
+	}
+	getResult, err := retry.Value(ctx, store.retryPolicy, "couchbase_lookup_in", isTransientCouchbaseError,
+		func(ctx context.Context) (*gocb.LookupInResult, error) {
+			return store.collection.LookupIn(documentID, ops, &gocb.LookupInOptions{Context: ctx})
+		})
+	if err != nil {
+		return fmt.Errorf("manager CouchbaseStore GetSubDocument LookupIn error %w", err)
+	}
+	err = getResult.ContentAt(0, subDocPtr)
+	if errors.Is(err, gocb.ErrPathNotFound) {
+		return fmt.Errorf("manager CouchbaseStore GetSubDocument getResult %q error %w: %w", path, ErrPathNotFound, err)
+	}
+	if err != nil {
+		return fmt.Errorf("manager CouchbaseStore GetSubDocument getResult %q error %w", path, err)
+	}
+	return nil
+}
+
+// mutateIn runs the sub-document mutations on the document
+func (store *CouchbaseStore) mutateIn(ctx context.Context, documentID string, mops []gocb.MutateInSpec) error {
+	upsertResult, err := retry.Value(ctx, store.retryPolicy, "couchbase_mutate_in", isTransientCouchbaseError,
+		func(ctx context.Context) (*gocb.MutateInResult, error) {
+			return store.collection.MutateIn(documentID, mops, &gocb.MutateInOptions{
+				Timeout: 10050 * time.Millisecond,
+				Context: ctx,
+			})
+		})
+	if err != nil {
+		return err
+	}
+	// a mutation that was applied has a token from the bucket of the store, if the cluster returns tokens
+	if token := upsertResult.MutationToken(); token != nil && token.BucketName() != store.bucket.Name() {
+		return fmt.Errorf("the mutation token is from the bucket %q, want %q", token.BucketName(), store.bucket.Name())
+	}
+	return nil
+}
+
+// UpsertSubDocument updates a Couchbase subdocument
+func (store *CouchbaseStore) UpsertSubDocument(ctx context.Context, documentID, path string, subDoc interface{}) error {
+	err := store.mutateIn(ctx, documentID, []gocb.MutateInSpec{
+		gocb.UpsertSpec(path, subDoc, &gocb.UpsertSpecOptions{}),
+	})
+	if err != nil {
+		return fmt.Errorf("manager CouchbaseStore UpsertSubDocument error: %w", err)
+	}
+	return nil
+}
+
+// SetStatus updates the couchbase scorecard document with the processing status
+func (store *CouchbaseStore) SetStatus(ctx context.Context, documentID, status string) error {
+	err := store.mutateIn(ctx, documentID, []gocb.MutateInSpec{
+		gocb.UpsertSpec("status", status, &gocb.UpsertSpecOptions{}),
+	})
+	if err != nil {
+		return fmt.Errorf("manager CouchbaseStore SetStatus error: %w", err)
+	}
+	return nil
+}
+
+// SetProcessedAt updates the couchbase scorecard document with the processed timestamp
+func (store *CouchbaseStore) SetProcessedAt(ctx context.Context, documentID string, processedAt time.Time) error {
+	err := store.mutateIn(ctx, documentID, []gocb.MutateInSpec{
+		gocb.UpsertSpec("processedAt", processedAt.Unix(), &gocb.UpsertSpecOptions{}),
+	})
+	if err != nil {
+		return fmt.Errorf("manager CouchbaseStore SetProcessedAt error: %w", err)
+	}
+	return nil
+}
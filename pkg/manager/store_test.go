@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/director"
+)
+
+func Test_loadCouchbaseEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    director.DbCredentials
+		wantErr bool
+	}{
+		{
+			name: "default keyspace",
+			env:  map[string]string{"CB_HOST": "host", "CB_USER": "user", "CB_PASSWORD": "password", "CB_BUCKET": "vxdata"},
+			want: director.DbCredentials{Host: "host", User: "user", Password: "password", Bucket: "vxdata", Scope: "_default", Collection: "SCORECARD"},
+		},
+		{
+			name: "configured keyspace",
+			env: map[string]string{"CB_HOST": "host", "CB_USER": "user", "CB_PASSWORD": "password", "CB_BUCKET": "vxdata_staging",
+				"CB_SCOPE": "staging", "CB_COLLECTION": "SCORECARD_STAGING"},
+			want: director.DbCredentials{Host: "host", User: "user", Password: "password", Bucket: "vxdata_staging", Scope: "staging", Collection: "SCORECARD_STAGING"},
+		},
+		{
+			name:    "no bucket",
+			env:     map[string]string{"CB_HOST": "host", "CB_USER": "user", "CB_PASSWORD": "password"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CB_HOST", "CB_USER", "CB_PASSWORD", "CB_BUCKET", "CB_SCOPE", "CB_COLLECTION"} {
+				t.Setenv(name, tt.env[name])
+			}
+			got, err := loadCouchbaseEnvironment()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadCouchbaseEnvironment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("loadCouchbaseEnvironment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
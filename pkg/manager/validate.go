@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/scorecard"
+)
+
+// getDocument retrieves the whole scorecard document
+func (mngr *Manager) getDocument(ctx context.Context) ([]byte, error) {
+	doc, err := mngr.store.GetDocument(ctx, mngr.documentID)
+	if err != nil {
+		return nil, fmt.Errorf("manager getDocument error %w", err)
+	}
+	return doc, nil
+}
+
+// validateDocument checks the structure of the document, it returns a *scorecard.ValidationError
+// if the document has problems
+func (mngr *Manager) validateDocument(ctx context.Context) error {
+	doc, err := mngr.getDocument(ctx)
+	if err != nil {
+		return err
+	}
+	if problems := scorecard.Validate(doc); len(problems) > 0 {
+		return &scorecard.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate reads the scorecard document and checks its structure without processing it, see scorecard.Validate.
+// An error is returned if the document can't be read, the problems of a document that was read are returned.
+func (mngr *Manager) Validate(ctx context.Context) ([]scorecard.Problem, error) {
+	closeStore, err := mngr.connectStore()
+	if err != nil {
+		return nil, fmt.Errorf("manager Validate error: %w", err)
+	}
+	defer closeStore()
+	doc, err := mngr.getDocument(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("manager Validate error: %w", err)
+	}
+	return scorecard.Validate(doc), nil
+}
@@ -0,0 +1,38 @@
+package retry
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var subsystem = "retry"
+
+var (
+	retries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "retries_total",
+			Help:      "Number of times an operation was run again after a transient failure.",
+		},
+		[]string{"operation"},
+	)
+
+	recovered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "recovered_total",
+			Help:      "Number of operations that succeeded after one or more retries.",
+		},
+		[]string{"operation"},
+	)
+
+	exhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "exhausted_total",
+			Help:      "Number of operations that still failed after all of their attempts.",
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(retries, recovered, exhausted)
+}
@@ -0,0 +1,92 @@
+// Package retry runs operations again after transient failures, with exponential backoff and jitter.
+//
+// The caller decides which errors are transient. A Policy with MaxAttempts of 1 (or the zero Policy)
+// runs the operation once, so retries can be turned off by configuration.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how often and how patiently an operation is retried
+type Policy struct {
+	MaxAttempts    int           // the number of times the operation is run, including the first time
+	InitialBackoff time.Duration // the wait before the first retry
+	MaxBackoff     time.Duration // the longest wait between two attempts
+	Multiplier     float64       // the growth of the wait after each retry, 2 if not set
+	Jitter         float64       // the fraction of each wait that is random, between 0 and 1
+}
+
+// DefaultPolicy is three attempts, waiting up to half a second before the first retry and up to a second before the second
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+}
+
+// backoff returns the wait before the given retry (retry 1 follows the first attempt)
+func (p Policy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	wait := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		wait *= multiplier
+		if p.MaxBackoff > 0 && wait >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	jitter := min(max(p.Jitter, 0), 1)
+	// a random wait between (1 - jitter) * wait and wait keeps concurrent retries apart
+	wait -= jitter * wait * rand.Float64() //nolint:gosec // the jitter doesn't need a secure random number
+	return time.Duration(wait)
+}
+
+// Do runs fn until it succeeds, it fails with an error that isRetryable rejects, the policy's attempts are
+// used up, or ctx is done. The operation names the metrics e.g. "mysql_query".
+func Do(ctx context.Context, policy Policy, operation string, isRetryable func(error) bool, fn func(ctx context.Context) error) error {
+	_, err := Value(ctx, policy, operation, isRetryable, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// Value is Do for an operation that returns a value
+func Value[T any](ctx context.Context, policy Policy, operation string, isRetryable func(error) bool, fn func(ctx context.Context) (T, error)) (T, error) {
+	attempts := max(policy.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		result, err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				recovered.WithLabelValues(operation).Inc()
+			}
+			return result, nil
+		}
+		if !isRetryable(err) || ctx.Err() != nil {
+			return result, err
+		}
+		if attempt >= attempts {
+			exhausted.WithLabelValues(operation).Inc()
+			return result, fmt.Errorf("retry %s gave up after %d attempts: %w", operation, attempt, err)
+		}
+		retries.WithLabelValues(operation).Inc()
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errPermanent = errors.New("permanent")
+)
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func testPolicy(attempts int) Policy {
+	return Policy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond, Jitter: 0.5}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       Policy
+		failures     []error // the errors of the first attempts, the attempt after them succeeds
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", policy: testPolicy(3), wantAttempts: 1},
+		{name: "recovers", policy: testPolicy(3), failures: []error{errTransient, errTransient}, wantAttempts: 3},
+		{name: "exhausted", policy: testPolicy(3), failures: []error{errTransient, errTransient, errTransient}, wantAttempts: 3, wantErr: errTransient},
+		{name: "permanent", policy: testPolicy(3), failures: []error{errPermanent}, wantAttempts: 1, wantErr: errPermanent},
+		{name: "transient then permanent", policy: testPolicy(3), failures: []error{errTransient, errPermanent}, wantAttempts: 2, wantErr: errPermanent},
+		{name: "zero policy runs once", policy: Policy{}, failures: []error{errTransient}, wantAttempts: 1, wantErr: errTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := "test_" + tt.name
+			attempts := 0
+			err := Do(context.Background(), tt.policy, operation, isTransient, func(ctx context.Context) error {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Do() ran %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			if got := testutil.ToFloat64(retries.WithLabelValues(operation)); int(got) != tt.wantAttempts-1 {
+				t.Errorf("retries_total = %v, want %d", got, tt.wantAttempts-1)
+			}
+		})
+	}
+}
+
+func TestDoCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 5, InitialBackoff: time.Hour}
+	attempts := 0
+	start := time.Now()
+	err := Do(ctx, policy, "test_cancel", isTransient, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || attempts != 1 {
+		t.Errorf("Do() = %v after %d attempts, want the transient error after 1", err, attempts)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Do() waited out the backoff after the context was cancelled")
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 3, max: 400 * time.Millisecond},
+		{retry: 5, max: time.Second},
+		{retry: 50, max: time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(tt.retry); got < tt.max/2 || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.retry, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
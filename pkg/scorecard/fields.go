@@ -0,0 +1,58 @@
+package scorecard
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// fields holds the members of a JSON object as they were read. The model types only declare the
+// members that the processor uses, the others are written back unchanged so that a document survives
+// a round trip through the model.
+type fields map[string]json.RawMessage
+
+// member is a declared member of a model type
+type member struct {
+	name  string
+	value any // a pointer to the field that holds the member
+}
+
+// decode reads a JSON object into the declared members and keeps all of its members
+func (f *fields) decode(data []byte, members ...member) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, m := range members {
+		value, ok := raw[m.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, m.value); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+	}
+	*f = raw
+	return nil
+}
+
+// encode writes the members that were read with the current values of the declared members.
+// A declared member that wasn't read is only written if it isn't the zero value.
+func (f fields) encode(members ...member) ([]byte, error) {
+	out := make(map[string]json.RawMessage, len(f)+len(members))
+	for name, value := range f {
+		out[name] = value
+	}
+	for _, m := range members {
+		_, read := f[m.name]
+		if !read && reflect.ValueOf(m.value).Elem().IsZero() {
+			continue
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.name, err)
+		}
+		out[m.name] = value
+	}
+	return json.Marshal(out)
+}
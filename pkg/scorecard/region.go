@@ -0,0 +1,137 @@
+package scorecard
+
+import (
+	"sort"
+	"strings"
+)
+
+// Region is the part of a block below a region, in the results and in the queryMap.
+// The keys are statistic -> variable -> threshold -> level -> forecast length and the
+// values are the cells (in the results) or their query templates (in the queryMap).
+type Region[T any] map[string]map[string]map[string]map[string]map[string]T
+
+// ResultsRegion is a region of a results block
+type ResultsRegion = Region[CellValue]
+
+// QueryRegion is a region of a queryMap block
+type QueryRegion = Region[QueryLeaf]
+
+// CellKeys are the keys of a cell below its region
+type CellKeys struct {
+	Statistic      string
+	Variable       string
+	Threshold      string
+	Level          string
+	ForecastLength string
+}
+
+// Keychain returns the keys of the cell starting with its region e.g.
+// All HRRR domain, RMSE, 2m temperature, threshold_NA, level_NA, 6
+func (k CellKeys) Keychain(region string) []string {
+	return []string{region, k.Statistic, k.Variable, k.Threshold, k.Level, k.ForecastLength}
+}
+
+// Path is the keychain of the cell joined with " -> ", like builder GetPath
+func (k CellKeys) Path(region string) string {
+	return strings.Join(k.Keychain(region), " -> ")
+}
+
+// String is the keys of the cell joined with " -> ", without its region
+func (k CellKeys) String() string {
+	return strings.Join(k.Keychain("")[1:], " -> ")
+}
+
+// Keys returns the keys of all the cells in the region, in key order
+func (r Region[T]) Keys() []CellKeys {
+	var keys []CellKeys
+	for _, statistic := range sortedKeys(r) {
+		for _, variable := range sortedKeys(r[statistic]) {
+			for _, threshold := range sortedKeys(r[statistic][variable]) {
+				for _, level := range sortedKeys(r[statistic][variable][threshold]) {
+					for _, fcst := range sortedKeys(r[statistic][variable][threshold][level]) {
+						keys = append(keys, CellKeys{statistic, variable, threshold, level, fcst})
+					}
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// Get returns the cell with the keys, if the region has it
+func (r Region[T]) Get(k CellKeys) (T, bool) {
+	value, ok := r[k.Statistic][k.Variable][k.Threshold][k.Level][k.ForecastLength]
+	return value, ok
+}
+
+// Set sets the cell with the keys, creating the elements above it if they don't exist.
+// It is not safe to call concurrently.
+func (r Region[T]) Set(k CellKeys, value T) {
+	variables, ok := r[k.Statistic]
+	if !ok {
+		variables = map[string]map[string]map[string]map[string]T{}
+		r[k.Statistic] = variables
+	}
+	thresholds, ok := variables[k.Variable]
+	if !ok {
+		thresholds = map[string]map[string]map[string]T{}
+		variables[k.Variable] = thresholds
+	}
+	levels, ok := thresholds[k.Threshold]
+	if !ok {
+		levels = map[string]map[string]T{}
+		thresholds[k.Threshold] = levels
+	}
+	cells, ok := levels[k.Level]
+	if !ok {
+		cells = map[string]T{}
+		levels[k.Level] = cells
+	}
+	cells[k.ForecastLength] = value
+}
+
+// Subset returns a region with the cells of r that have the keys, the cells are shared with r
+func (r Region[T]) Subset(keys []CellKeys) Region[T] {
+	subset := Region[T]{}
+	for _, k := range keys {
+		if value, ok := r.Get(k); ok {
+			subset.Set(k, value)
+		}
+	}
+	return subset
+}
+
+// Subtree returns the part of r below the keys, the first keys of a cell, and whether r has it.
+// No keys is the region, a statistic is the map of its variables and all the keys of a cell is its value.
+func (r Region[T]) Subtree(keys []string) (interface{}, bool) {
+	switch len(keys) {
+	case 0:
+		return r, true
+	case 1:
+		subtree, ok := r[keys[0]]
+		return subtree, ok
+	case 2:
+		subtree, ok := r[keys[0]][keys[1]]
+		return subtree, ok
+	case 3:
+		subtree, ok := r[keys[0]][keys[1]][keys[2]]
+		return subtree, ok
+	case 4:
+		subtree, ok := r[keys[0]][keys[1]][keys[2]][keys[3]]
+		return subtree, ok
+	case 5:
+		subtree, ok := r[keys[0]][keys[1]][keys[2]][keys[3]][keys[4]]
+		return subtree, ok
+	default:
+		return nil, false
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
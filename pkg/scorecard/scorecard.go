@@ -0,0 +1,230 @@
+// Package scorecard is a typed model of the scorecard document. The types only declare the
+// members that the processor reads or writes, every other member of the document is kept as it
+// was read so that a document survives a round trip through the model unchanged.
+package scorecard
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Document is a scorecard document e.g.
+// {"id": "SC:...", "type": "SC", "dateRange": "...", "plotParams": {...}, "queryMap": {...}, "results": {...}, ...}
+type Document struct {
+	ID         string
+	DateRange  string
+	Status     string
+	PlotParams PlotParams
+	QueryMap   QueryMap
+	Results    Results
+	fields     fields
+}
+
+func (d *Document) members() []member {
+	return []member{
+		{"id", &d.ID},
+		{"dateRange", &d.DateRange},
+		{"status", &d.Status},
+		{"plotParams", &d.PlotParams},
+		{"queryMap", &d.QueryMap},
+		{"results", &d.Results},
+	}
+}
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	if err := d.fields.decode(data, d.members()...); err != nil {
+		return fmt.Errorf("scorecard Document %w", err)
+	}
+	return nil
+}
+
+func (d Document) MarshalJSON() ([]byte, error) {
+	return d.fields.encode(d.members()...)
+}
+
+// PlotParams are the parameters the scorecard was submitted with
+type PlotParams struct {
+	Curves                  []Curve
+	PercentStdv             string // "Percent" or "Standard Deviation"
+	MinorThresholdByPercent Setting
+	MajorThresholdByPercent Setting
+	MinorThresholdByStdv    Setting
+	MajorThresholdByStdv    Setting
+	// date ranges whose valid times are left out of the statistics e.g. an obs outage, see the daterange package
+	Exclusions []string
+	// the UTC hours 0 to 23 of the valid times and of the init times that the statistics use, empty is every hour
+	ValidHours []int
+	InitHours  []int
+	fields     fields
+}
+
+func (p *PlotParams) members() []member {
+	return []member{
+		{"curves", &p.Curves},
+		{"scorecard-percent-stdv", &p.PercentStdv},
+		{"minor-threshold-by-percent", &p.MinorThresholdByPercent},
+		{"major-threshold-by-percent", &p.MajorThresholdByPercent},
+		{"minor-threshold-by-stdv", &p.MinorThresholdByStdv},
+		{"major-threshold-by-stdv", &p.MajorThresholdByStdv},
+		{"exclusions", &p.Exclusions},
+		{"valid-hours", &p.ValidHours},
+		{"init-hours", &p.InitHours},
+	}
+}
+
+func (p *PlotParams) UnmarshalJSON(data []byte) error {
+	return p.fields.decode(data, p.members()...)
+}
+
+func (p PlotParams) MarshalJSON() ([]byte, error) {
+	return p.fields.encode(p.members()...)
+}
+
+// Curve is a curve of the plotParams, each block of the scorecard has a curve with the same label
+type Curve struct {
+	Label       string
+	Application string
+	fields      fields
+}
+
+func (c *Curve) members() []member {
+	return []member{
+		{"label", &c.Label},
+		{"application", &c.Application},
+	}
+}
+
+func (c *Curve) UnmarshalJSON(data []byte) error {
+	return c.fields.decode(data, c.members()...)
+}
+
+func (c Curve) MarshalJSON() ([]byte, error) {
+	return c.fields.encode(c.members()...)
+}
+
+// Parameters returns all the members of the curve, with the values as encoding/json decodes them
+// into an interface{}. The query template variables are taken from the parameters.
+func (c Curve) Parameters() (map[string]interface{}, error) {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("scorecard Curve.Parameters %w", err)
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("scorecard Curve.Parameters %w", err)
+	}
+	return params, nil
+}
+
+// Results holds the results blocks, keyed by the block (curve) label
+type Results struct {
+	Blocks map[string]*ResultsBlock
+	fields fields
+}
+
+func (r *Results) members() []member {
+	return []member{{"blocks", &r.Blocks}}
+}
+
+func (r *Results) UnmarshalJSON(data []byte) error {
+	return r.fields.decode(data, r.members()...)
+}
+
+func (r Results) MarshalJSON() ([]byte, error) {
+	return r.fields.encode(r.members()...)
+}
+
+// ResultsBlock is a block of the results, the data holds a region for each region of the block
+type ResultsBlock struct {
+	BlockApplication string
+	BlockTitle       BlockTitle
+	Data             map[string]ResultsRegion
+	fields           fields
+}
+
+func (b *ResultsBlock) members() []member {
+	return []member{
+		{"blockApplication", &b.BlockApplication},
+		{"blockTitle", &b.BlockTitle},
+		{"data", &b.Data},
+	}
+}
+
+func (b *ResultsBlock) UnmarshalJSON(data []byte) error {
+	return b.fields.decode(data, b.members()...)
+}
+
+func (b ResultsBlock) MarshalJSON() ([]byte, error) {
+	return b.fields.encode(b.members()...)
+}
+
+// BlockTitle names the curve and the data sources of a block
+type BlockTitle struct {
+	Label             string
+	DataSource        string
+	ControlDataSource string
+	fields            fields
+}
+
+func (t *BlockTitle) members() []member {
+	return []member{
+		{"label", &t.Label},
+		{"dataSource", &t.DataSource},
+		{"controlDataSource", &t.ControlDataSource},
+	}
+}
+
+func (t *BlockTitle) UnmarshalJSON(data []byte) error {
+	return t.fields.decode(data, t.members()...)
+}
+
+func (t BlockTitle) MarshalJSON() ([]byte, error) {
+	return t.fields.encode(t.members()...)
+}
+
+// QueryMap holds the query blocks, keyed by the block (curve) label
+type QueryMap struct {
+	Blocks map[string]*QueryBlock
+	fields fields
+}
+
+func (q *QueryMap) members() []member {
+	return []member{{"blocks", &q.Blocks}}
+}
+
+func (q *QueryMap) UnmarshalJSON(data []byte) error {
+	return q.fields.decode(data, q.members()...)
+}
+
+func (q QueryMap) MarshalJSON() ([]byte, error) {
+	return q.fields.encode(q.members()...)
+}
+
+// QueryBlock is a block of the queryMap, the data holds the query templates for each region of the block
+type QueryBlock struct {
+	Data   map[string]QueryRegion
+	fields fields
+}
+
+func (b *QueryBlock) members() []member {
+	return []member{{"data", &b.Data}}
+}
+
+func (b *QueryBlock) UnmarshalJSON(data []byte) error {
+	return b.fields.decode(data, b.members()...)
+}
+
+func (b QueryBlock) MarshalJSON() ([]byte, error) {
+	return b.fields.encode(b.members()...)
+}
+
+// QueryLeaf holds the query templates of a cell
+type QueryLeaf struct {
+	ControlQueryTemplate      string `json:"controlQueryTemplate"`      //nolint:tagliatelle // the scorecard document is camelCase
+	ExperimentalQueryTemplate string `json:"experimentalQueryTemplate"` //nolint:tagliatelle // the scorecard document is camelCase
+}
+
+// CellValue is the value of a results cell. It is -9999 before the cell is processed and
+// a builder.ValueStruct or a builder.ErrorValue after. Values that were read from a document
+// are what encoding/json decodes into an interface{}.
+type CellValue = interface{}
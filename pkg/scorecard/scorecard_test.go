@@ -0,0 +1,127 @@
+package scorecard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the test documents of the manager are complete scorecards
+const testDocuments = "../manager/testdata/test_*.json"
+
+func TestDocument_roundTrip(t *testing.T) {
+	paths, err := filepath.Glob(testDocuments)
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no test documents %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc Document
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("Unmarshal() error %v", err)
+			}
+			if doc.ID == "" || len(doc.PlotParams.Curves) == 0 || len(doc.Results.Blocks) == 0 || len(doc.QueryMap.Blocks) == 0 {
+				t.Fatalf("Unmarshal() = id %q %d curves %d results blocks %d query blocks",
+					doc.ID, len(doc.PlotParams.Curves), len(doc.Results.Blocks), len(doc.QueryMap.Blocks))
+			}
+			out, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("Marshal() error %v", err)
+			}
+			var want, got interface{}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("the document changed in a round trip")
+			}
+		})
+	}
+}
+
+func TestDocument_modified(t *testing.T) {
+	data := `{"id": "SC:1", "extra": {"kept": [1, 2]}, "results": {"blocks": {"Block0": {"blockTitle": {"label": "Block0", "other": "x"},
+		"data": {"All HRRR domain": {"RMSE": {"2m temperature": {"threshold_NA": {"level_NA": {"6": -9999}}}}}}}}}}`
+	var doc Document
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("Unmarshal() error %v", err)
+	}
+	keys := CellKeys{"RMSE", "2m temperature", "threshold_NA", "level_NA", "6"}
+	region := doc.Results.Blocks["Block0"].Data["All HRRR domain"]
+	if value, ok := region.Get(keys); !ok || value != -9999.0 {
+		t.Fatalf("Get() = %v, %v", value, ok)
+	}
+	region.Set(keys, map[string]int{"Value": 2})
+	region.Set(CellKeys{"Bias", "2m temperature", "threshold_NA", "level_NA", "6"}, -9999)
+	doc.Status = "ready"
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error %v", err)
+	}
+	want := `{"extra":{"kept":[1,2]},"id":"SC:1","results":{"blocks":{"Block0":{"blockTitle":{"label":"Block0","other":"x"},` +
+		`"data":{"All HRRR domain":{"Bias":{"2m temperature":{"threshold_NA":{"level_NA":{"6":-9999}}}},` +
+		`"RMSE":{"2m temperature":{"threshold_NA":{"level_NA":{"6":{"Value":2}}}}}}}}}},"status":"ready"}`
+	if string(out) != want {
+		t.Errorf("Marshal() = %s\nwant %s", out, want)
+	}
+	if got := region.Keys(); len(got) != 2 || got[0].Statistic != "Bias" || got[1] != keys {
+		t.Errorf("Keys() = %v", got)
+	}
+	if got := keys.Path("All HRRR domain"); got != "All HRRR domain -> RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6" {
+		t.Errorf("Path() = %q", got)
+	}
+	if got := keys.String(); got != "RMSE -> 2m temperature -> threshold_NA -> level_NA -> 6" {
+		t.Errorf("String() = %q", got)
+	}
+	subset := region.Subset([]CellKeys{keys, {"MAE", "2m temperature", "threshold_NA", "level_NA", "6"}})
+	if got := subset.Keys(); len(got) != 1 || got[0] != keys {
+		t.Errorf("Subset() has the keys %v, want %v", got, keys)
+	}
+	subset.Set(keys, -9999)
+	if value, _ := region.Get(keys); value == -9999 {
+		t.Errorf("setting a cell of the subset set the cell of the region")
+	}
+	if subtree, ok := region.Subtree([]string{"RMSE", "2m temperature"}); !ok || !reflect.DeepEqual(subtree, region["RMSE"]["2m temperature"]) {
+		t.Errorf("Subtree() = %v, %v", subtree, ok)
+	}
+	if value, ok := region.Subtree(keys.Keychain("")[1:]); !ok || !reflect.DeepEqual(value, map[string]int{"Value": 2}) {
+		t.Errorf("Subtree() of a cell = %v, %v", value, ok)
+	}
+	if _, ok := region.Subtree([]string{"MAE"}); ok {
+		t.Errorf("Subtree() of a missing statistic is ok")
+	}
+}
+
+func TestDocument_malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "not an object", data: `[]`, wantErr: "cannot unmarshal array"},
+		{name: "curves", data: `{"plotParams": {"curves": {"label": "x"}}}`, wantErr: "plotParams: curves:"},
+		{name: "curve label", data: `{"plotParams": {"curves": [{"label": 1}]}}`, wantErr: "plotParams: curves: label:"},
+		{name: "results data", data: `{"results": {"blocks": {"Block0": {"data": {"r": {"RMSE": "x"}}}}}}`, wantErr: "results: blocks: data:"},
+		{name: "query leaf", data: `{"queryMap": {"blocks": {"Block0": {"data": {"r": {"s": {"v": {"t": {"l": {"6": 1}}}}}}}}}}`, wantErr: "queryMap: blocks: data:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc Document
+			err := json.Unmarshal([]byte(tt.data), &doc)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package scorecard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Setting is a threshold setting of the plotParams. The scorecard app writes the settings as strings
+// e.g. "95", a setting may also be a number e.g. 95 or 2.5. It is written back the way it was read.
+type Setting struct {
+	Text   string // the setting, a number is its JSON literal
+	number bool   // the setting was a JSON number
+}
+
+// String returns the text of the setting
+func (s Setting) String() string {
+	return s.Text
+}
+
+func (s *Setting) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case nil:
+		*s = Setting{}
+	case string:
+		*s = Setting{Text: value}
+	case json.Number:
+		*s = Setting{Text: value.String(), number: true}
+	default:
+		return fmt.Errorf("the threshold setting %s is not a string or a number", data)
+	}
+	return nil
+}
+
+func (s Setting) MarshalJSON() ([]byte, error) {
+	if s.number {
+		if _, err := strconv.ParseFloat(s.Text, 64); err == nil {
+			return []byte(s.Text), nil
+		}
+	}
+	return json.Marshal(s.Text)
+}
+
+// Thresholds are the significance thresholds of a scorecard as confidence percents, with the settings that they
+// come from. They are written to the thresholds member of the document so that its results can be traced to them.
+type Thresholds struct {
+	PercentStdv  string  `json:"percent_stdv"`  // "Percent" or "Standard Deviation"
+	Minor        string  `json:"minor"`         // the minor setting, a percent or a number of standard deviations
+	Major        string  `json:"major"`         // the major setting, a percent or a number of standard deviations
+	MinorPercent float64 `json:"minor_percent"` // the minor confidence in percent
+	MajorPercent float64 `json:"major_percent"` // the major confidence in percent
+}
+
+// ThresholdError is the error of a threshold setting, Member is the plotParams member that is wrong
+type ThresholdError struct {
+	Member string
+	Err    error
+}
+
+func (e *ThresholdError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ThresholdError) Unwrap() error {
+	return e.Err
+}
+
+// Thresholds returns the minor and major significance thresholds of the scorecard. The major threshold
+// must be stricter than the minor threshold. The error is a *ThresholdError.
+func (p PlotParams) Thresholds() (Thresholds, error) {
+	thresholds := Thresholds{PercentStdv: p.PercentStdv}
+	var minorName, majorName string
+	var parse func(string) (float64, error)
+	switch p.PercentStdv {
+	case "Percent":
+		minorName, majorName = "minor-threshold-by-percent", "major-threshold-by-percent"
+		thresholds.Minor, thresholds.Major = p.MinorThresholdByPercent.Text, p.MajorThresholdByPercent.Text
+		parse = PercentThreshold
+	case "Standard Deviation":
+		minorName, majorName = "minor-threshold-by-stdv", "major-threshold-by-stdv"
+		thresholds.Minor, thresholds.Major = p.MinorThresholdByStdv.Text, p.MajorThresholdByStdv.Text
+		parse = StdvToPercent
+	default:
+		return Thresholds{}, &ThresholdError{Member: "scorecard-percent-stdv",
+			Err: fmt.Errorf("scorecard Thresholds error scorecard-percent-stdv is %q, want \"Percent\" or \"Standard Deviation\"", p.PercentStdv)}
+	}
+	var err error
+	thresholds.MinorPercent, err = parse(thresholds.Minor)
+	if err != nil {
+		return Thresholds{}, &ThresholdError{Member: minorName, Err: err}
+	}
+	thresholds.MajorPercent, err = parse(thresholds.Major)
+	if err != nil {
+		return Thresholds{}, &ThresholdError{Member: majorName, Err: err}
+	}
+	if err := thresholds.check(); err != nil {
+		return Thresholds{}, &ThresholdError{Member: majorName, Err: fmt.Errorf("scorecard Thresholds error %w", err)}
+	}
+	return thresholds, nil
+}
+
+// thresholdMember returns the plotParams member that a Thresholds error is about
+func thresholdMember(err error) string {
+	var thresholdErr *ThresholdError
+	if errors.As(err, &thresholdErr) {
+		return thresholdErr.Member
+	}
+	return "scorecard-percent-stdv"
+}
+
+// check returns an error if the major threshold isn't stricter than the minor threshold
+func (t Thresholds) check() error {
+	if t.MajorPercent <= t.MinorPercent {
+		return fmt.Errorf("the major threshold %s (%v%%) is not stricter than the minor threshold %s (%v%%)",
+			t.Major, t.MajorPercent, t.Minor, t.MinorPercent)
+	}
+	return nil
+}
+
+// PercentThreshold parses a threshold that is given as a percent, it is between 0 and 100 exclusive
+func PercentThreshold(percent string) (float64, error) {
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+	if err != nil {
+		return 0, fmt.Errorf("scorecard PercentThreshold error parsing %q: %w", percent, err)
+	}
+	if !(threshold > 0 && threshold < 100) {
+		return 0, fmt.Errorf("scorecard PercentThreshold error %q is not between 0 and 100 exclusive", percent)
+	}
+	return threshold, nil
+}
+
+// StdvToPercent converts a threshold that is given as a number of standard deviations to the percent of a
+// normal distribution that is within that many standard deviations of the mean, e.g. 1 is 68.27, 2 is 95.45
+// and 1.96 is 95.00
+func StdvToPercent(std string) (percent float64, err error) {
+	stdfloat, err := strconv.ParseFloat(strings.TrimSpace(std), 64)
+	if err != nil {
+		err = fmt.Errorf("scorecard StdvToPercent error converting standard deviation %q to percent error: %w", std, err)
+		return 0, err
+	}
+	if !(stdfloat > 0) || math.IsInf(stdfloat, 1) {
+		return 0, fmt.Errorf("scorecard StdvToPercent error standard deviation %q is not a positive number", std)
+	}
+	// the normal CDF within stdfloat of the mean, 2*Phi(stdfloat)-1
+	percent = 100 * math.Erf(stdfloat/math.Sqrt2)
+	if percent >= 100 {
+		return 0, fmt.Errorf("scorecard StdvToPercent error standard deviation %q is too large, its confidence rounds to 100%%", std)
+	}
+	return percent, nil
+}
@@ -0,0 +1,167 @@
+package scorecard
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStdvToPercent(t *testing.T) {
+	tests := []struct {
+		std     string
+		want    float64
+		wantErr string
+	}{
+		{std: "1", want: 68.2689},
+		{std: "2", want: 95.4500},
+		{std: "3", want: 99.7300},
+		{std: "1.96", want: 95.0004},
+		{std: " 2.576 ", want: 99.0005},
+		{std: "0.5", want: 38.2925},
+		{std: "0", wantErr: "is not a positive number"},
+		{std: "-1", wantErr: "is not a positive number"},
+		{std: "NaN", wantErr: "is not a positive number"},
+		{std: "+Inf", wantErr: "is not a positive number"},
+		{std: "9", wantErr: "is too large"},
+		{std: "two", wantErr: "converting standard deviation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.std, func(t *testing.T) {
+			got, err := StdvToPercent(tt.std)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("StdvToPercent() = %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("StdvToPercent() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentThreshold(t *testing.T) {
+	tests := []struct {
+		percent string
+		want    float64
+		wantErr string
+	}{
+		{percent: "95", want: 95},
+		{percent: "99.9", want: 99.9},
+		{percent: "0", wantErr: "is not between 0 and 100"},
+		{percent: "100", wantErr: "is not between 0 and 100"},
+		{percent: "NaN", wantErr: "is not between 0 and 100"},
+		{percent: "95%", wantErr: "error parsing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.percent, func(t *testing.T) {
+			got, err := PercentThreshold(tt.percent)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("PercentThreshold() = %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("PercentThreshold() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlotParams_Thresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		plotParams string
+		want       Thresholds
+		wantErr    string
+		wantMember string // the plotParams member of the error
+	}{
+		{
+			name:       "percent strings",
+			plotParams: `{"scorecard-percent-stdv": "Percent", "minor-threshold-by-percent": "95", "major-threshold-by-percent": "99"}`,
+			want:       Thresholds{PercentStdv: "Percent", Minor: "95", Major: "99", MinorPercent: 95, MajorPercent: 99},
+		},
+		{
+			name:       "percent numbers",
+			plotParams: `{"scorecard-percent-stdv": "Percent", "minor-threshold-by-percent": 90.5, "major-threshold-by-percent": 99}`,
+			want:       Thresholds{PercentStdv: "Percent", Minor: "90.5", Major: "99", MinorPercent: 90.5, MajorPercent: 99},
+		},
+		{
+			name:       "standard deviations",
+			plotParams: `{"scorecard-percent-stdv": "Standard Deviation", "minor-threshold-by-stdv": 1.96, "major-threshold-by-stdv": "3"}`,
+			want: Thresholds{PercentStdv: "Standard Deviation", Minor: "1.96", Major: "3",
+				MinorPercent: 100 * math.Erf(1.96/math.Sqrt2), MajorPercent: 100 * math.Erf(3/math.Sqrt2)},
+		},
+		{
+			name:       "major equals minor",
+			plotParams: `{"scorecard-percent-stdv": "Percent", "minor-threshold-by-percent": "95", "major-threshold-by-percent": 95}`,
+			wantErr:    "the major threshold 95 (95%) is not stricter than the minor threshold 95 (95%)",
+			wantMember: "major-threshold-by-percent",
+		},
+		{
+			name:       "major less strict",
+			plotParams: `{"scorecard-percent-stdv": "Standard Deviation", "minor-threshold-by-stdv": "2", "major-threshold-by-stdv": "1"}`,
+			wantErr:    "is not stricter than the minor threshold 2",
+			wantMember: "major-threshold-by-stdv",
+		},
+		{
+			name:       "kind",
+			plotParams: `{"scorecard-percent-stdv": "Sigma", "minor-threshold-by-stdv": "2", "major-threshold-by-stdv": "3"}`,
+			wantErr:    `is "Sigma"`,
+			wantMember: "scorecard-percent-stdv",
+		},
+		{
+			name:       "minor",
+			plotParams: `{"scorecard-percent-stdv": "Percent", "minor-threshold-by-percent": "95%", "major-threshold-by-percent": "99"}`,
+			wantErr:    "error parsing",
+			wantMember: "minor-threshold-by-percent",
+		},
+		{
+			name:       "major",
+			plotParams: `{"scorecard-percent-stdv": "Standard Deviation", "minor-threshold-by-stdv": "2", "major-threshold-by-stdv": "-1"}`,
+			wantErr:    "is not a positive number",
+			wantMember: "major-threshold-by-stdv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p PlotParams
+			if err := json.Unmarshal([]byte(tt.plotParams), &p); err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Thresholds()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Thresholds() = %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				if member := thresholdMember(err); member != tt.wantMember {
+					t.Errorf("Thresholds() error of the member %q, want %q", member, tt.wantMember)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Thresholds() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetting_roundTrip(t *testing.T) {
+	for _, data := range []string{`"95"`, `95`, `2.5e0`, `""`} {
+		var s Setting
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			t.Fatalf("Unmarshal(%s) error %v", data, err)
+		}
+		got, err := json.Marshal(s)
+		if err != nil || string(got) != data {
+			t.Errorf("Marshal() = %s, %v, want %s", got, err, data)
+		}
+	}
+	var s Setting
+	if err := json.Unmarshal([]byte(`[95]`), &s); err == nil || !strings.Contains(err.Error(), "is not a string or a number") {
+		t.Errorf("Unmarshal([95]) error = %v, want not a string or a number", err)
+	}
+}
@@ -0,0 +1,382 @@
+package scorecard
+
+/*
+Validate checks the structure of a scorecard document before it is processed. It reports every
+problem that would make the processing of the document fail with the JSON path of the member
+that has the problem, e.g.
+
+	$.queryMap.blocks.Block0.data["All HRRR domain"].RMSE["2m temperature"].threshold_NA.level_NA["6"]: is missing
+
+The structure is checked first on the decoded JSON, so that a member with the wrong type is reported
+where it is. Only a document with the right structure is checked for consistency: the results and
+the queryMap have the same blocks and regions, every results leaf has a queryMap leaf, every block
+has a plotParams curve, the date range and the exclusions parse and the threshold settings parse and have a major threshold
+that is stricter than the minor threshold.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NOAA-GSL/vxDataProcessor/pkg/daterange"
+)
+
+// regionDepth is the number of keys below a region: statistic, variable, threshold, level, forecast length
+const regionDepth = 5
+
+// Problem is a problem with a scorecard document
+type Problem struct {
+	Path    string `json:"path"` // the JSON path of the member that has the problem
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// ValidationError is returned for a document that has problems
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	const shown = 3
+	messages := make([]string, 0, shown)
+	for i, p := range e.Problems {
+		if i == shown {
+			messages = append(messages, fmt.Sprintf("and %d more", len(e.Problems)-shown))
+			break
+		}
+		messages = append(messages, p.String())
+	}
+	return fmt.Sprintf("scorecard document has %d problems: %s", len(e.Problems), strings.Join(messages, "; "))
+}
+
+// Validate checks a scorecard document, it returns no problems for a valid document
+func Validate(data []byte) []Problem {
+	var v validator
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		v.add("$", "is not JSON: %v", err)
+		return v.problems
+	}
+	v.checkStructure(raw)
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// the structure check should have found it
+		v.add("$", "%v", err)
+		return v.problems
+	}
+	v.checkConsistency(&doc)
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(path, format string, a ...any) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// memberPath is the path of a member of the object at path
+func memberPath(path, name string) string {
+	if identifier.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+// cellPath is the path of a cell of the region at path
+func cellPath(path string, k CellKeys) string {
+	for _, key := range []string{k.Statistic, k.Variable, k.Threshold, k.Level, k.ForecastLength} {
+		path = memberPath(path, key)
+	}
+	return path
+}
+
+// typeName is the JSON type of a decoded value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// object checks that value is an object
+func (v *validator) object(path string, value interface{}) (map[string]interface{}, bool) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		v.add(path, "is %s, want an object", typeName(value))
+	}
+	return obj, ok
+}
+
+// member checks that obj has the member name and returns its value and path
+func (v *validator) member(obj map[string]interface{}, path, name string) (interface{}, string, bool) {
+	path = memberPath(path, name)
+	value, ok := obj[name]
+	if !ok {
+		v.add(path, "is missing")
+	}
+	return value, path, ok
+}
+
+func (v *validator) objectMember(obj map[string]interface{}, path, name string) (map[string]interface{}, string, bool) {
+	value, path, ok := v.member(obj, path, name)
+	if !ok {
+		return nil, path, false
+	}
+	child, ok := v.object(path, value)
+	return child, path, ok
+}
+
+func (v *validator) stringMember(obj map[string]interface{}, path, name string) {
+	value, path, ok := v.member(obj, path, name)
+	if _, isString := value.(string); ok && !isString {
+		v.add(path, "is %s, want a string", typeName(value))
+	}
+}
+
+// optionalStringMembers checks that the members of obj that are present are strings
+func (v *validator) optionalStringMembers(obj map[string]interface{}, path string, names ...string) {
+	for _, name := range names {
+		value, ok := obj[name]
+		if _, isString := value.(string); ok && !isString {
+			v.add(memberPath(path, name), "is %s, want a string", typeName(value))
+		}
+	}
+}
+
+// checkStructure checks the members and their types
+func (v *validator) checkStructure(raw interface{}) {
+	doc, ok := v.object("$", raw)
+	if !ok {
+		return
+	}
+	v.stringMember(doc, "$", "dateRange")
+	v.optionalStringMembers(doc, "$", "id", "status")
+	if plotParams, path, ok := v.objectMember(doc, "$", "plotParams"); ok {
+		v.checkPlotParams(plotParams, path)
+	}
+	if results, path, ok := v.objectMember(doc, "$", "results"); ok {
+		if blocks, path, ok := v.objectMember(results, path, "blocks"); ok {
+			for _, name := range sortedKeys(blocks) {
+				v.checkResultsBlock(blocks[name], memberPath(path, name))
+			}
+		}
+	}
+	if queryMap, path, ok := v.objectMember(doc, "$", "queryMap"); ok {
+		if blocks, path, ok := v.objectMember(queryMap, path, "blocks"); ok {
+			for _, name := range sortedKeys(blocks) {
+				v.checkQueryBlock(blocks[name], memberPath(path, name))
+			}
+		}
+	}
+}
+
+func (v *validator) checkPlotParams(plotParams map[string]interface{}, path string) {
+	v.stringMember(plotParams, path, "scorecard-percent-stdv")
+	for _, name := range []string{"minor-threshold-by-percent", "major-threshold-by-percent", "minor-threshold-by-stdv", "major-threshold-by-stdv"} {
+		switch value, ok := plotParams[name]; value.(type) {
+		case string, float64:
+		default:
+			if ok {
+				v.add(memberPath(path, name), "is %s, want a string or a number", typeName(value))
+			}
+		}
+	}
+	if value, ok := plotParams["exclusions"]; ok {
+		exclusionsPath := memberPath(path, "exclusions")
+		if exclusions, ok := value.([]interface{}); ok {
+			for i, exclusion := range exclusions {
+				if _, isString := exclusion.(string); !isString {
+					v.add(exclusionsPath+"["+strconv.Itoa(i)+"]", "is %s, want a string", typeName(exclusion))
+				}
+			}
+		} else {
+			v.add(exclusionsPath, "is %s, want an array", typeName(value))
+		}
+	}
+	for _, name := range []string{"valid-hours", "init-hours"} {
+		if value, ok := plotParams[name]; ok {
+			v.checkHours(value, memberPath(path, name))
+		}
+	}
+	value, curvesPath, ok := v.member(plotParams, path, "curves")
+	if !ok {
+		return
+	}
+	curves, ok := value.([]interface{})
+	if !ok {
+		v.add(curvesPath, "is %s, want an array", typeName(value))
+		return
+	}
+	for i, c := range curves {
+		curvePath := curvesPath + "[" + strconv.Itoa(i) + "]"
+		if curve, ok := v.object(curvePath, c); ok {
+			v.stringMember(curve, curvePath, "label")
+			v.stringMember(curve, curvePath, "application")
+		}
+	}
+}
+
+// checkHours checks that value is an array of UTC hours
+func (v *validator) checkHours(value interface{}, path string) {
+	hours, ok := value.([]interface{})
+	if !ok {
+		v.add(path, "is %s, want an array of hours", typeName(value))
+		return
+	}
+	for i, hour := range hours {
+		if h, isNumber := hour.(float64); !isNumber || h != math.Trunc(h) || h < 0 || h > 23 {
+			v.add(path+"["+strconv.Itoa(i)+"]", "is %v, want an hour from 0 to 23", hour)
+		}
+	}
+}
+
+func (v *validator) checkResultsBlock(value interface{}, path string) {
+	block, ok := v.object(path, value)
+	if !ok {
+		return
+	}
+	v.stringMember(block, path, "blockApplication")
+	if title, titlePath, ok := v.objectMember(block, path, "blockTitle"); ok {
+		v.stringMember(title, titlePath, "label")
+		v.optionalStringMembers(title, titlePath, "dataSource", "controlDataSource")
+	}
+	if data, dataPath, ok := v.objectMember(block, path, "data"); ok {
+		for _, name := range sortedKeys(data) {
+			// a results leaf is -9999 or the value of a processed cell
+			v.checkRegion(data[name], memberPath(dataPath, name), regionDepth, func(interface{}, string) {})
+		}
+	}
+}
+
+func (v *validator) checkQueryBlock(value interface{}, path string) {
+	block, ok := v.object(path, value)
+	if !ok {
+		return
+	}
+	if data, dataPath, ok := v.objectMember(block, path, "data"); ok {
+		for _, name := range sortedKeys(data) {
+			v.checkRegion(data[name], memberPath(dataPath, name), regionDepth, v.checkQueryLeaf)
+		}
+	}
+}
+
+func (v *validator) checkQueryLeaf(value interface{}, path string) {
+	leaf, ok := v.object(path, value)
+	if !ok {
+		return
+	}
+	for _, name := range []string{"controlQueryTemplate", "experimentalQueryTemplate"} {
+		template, templatePath, ok := v.member(leaf, path, name)
+		if !ok {
+			continue
+		}
+		if s, isString := template.(string); !isString {
+			v.add(templatePath, "is %s, want a string", typeName(template))
+		} else if s == "" {
+			v.add(templatePath, "is empty")
+		}
+	}
+}
+
+// checkRegion checks that a region has depth levels of objects above its leaves
+func (v *validator) checkRegion(value interface{}, path string, depth int, checkLeaf func(interface{}, string)) {
+	if depth == 0 {
+		checkLeaf(value, path)
+		return
+	}
+	elem, ok := v.object(path, value)
+	if !ok {
+		return
+	}
+	for _, name := range sortedKeys(elem) {
+		v.checkRegion(elem[name], memberPath(path, name), depth-1, checkLeaf)
+	}
+}
+
+// checkConsistency checks a document that has the right structure
+func (v *validator) checkConsistency(doc *Document) {
+	if _, err := daterange.Parse(doc.DateRange, time.Now()); err != nil {
+		v.add("$.dateRange", "%v", err)
+	}
+	v.checkThresholds(doc.PlotParams)
+	for i, exclusion := range doc.PlotParams.Exclusions {
+		if _, err := daterange.Parse(exclusion, time.Now()); err != nil {
+			v.add("$.plotParams.exclusions["+strconv.Itoa(i)+"]", "%v", err)
+		}
+	}
+	labels := map[string]bool{}
+	for _, curve := range doc.PlotParams.Curves {
+		labels[curve.Label] = true
+	}
+	for _, name := range sortedKeys(doc.Results.Blocks) {
+		blockPath := memberPath("$.results.blocks", name)
+		block := doc.Results.Blocks[name]
+		if !labels[block.BlockTitle.Label] {
+			v.add(memberPath(blockPath, "blockTitle")+".label", "%q is not the label of a plotParams curve", block.BlockTitle.Label)
+		}
+		queryBlockPath := memberPath("$.queryMap.blocks", name)
+		queryBlock, ok := doc.QueryMap.Blocks[name]
+		if !ok {
+			v.add(queryBlockPath, "is missing, the results have the block")
+			continue
+		}
+		for _, regionName := range sortedKeys(block.Data) {
+			regionPath := memberPath(blockPath+".data", regionName)
+			queryRegionPath := memberPath(queryBlockPath+".data", regionName)
+			queryRegion, ok := queryBlock.Data[regionName]
+			if !ok {
+				v.add(queryRegionPath, "is missing, the results have the region")
+				continue
+			}
+			for _, k := range block.Data[regionName].Keys() {
+				if _, ok := queryRegion.Get(k); !ok {
+					v.add(cellPath(queryRegionPath, k), "is missing, the results have the cell %s", cellPath(regionPath, k))
+				}
+			}
+		}
+		for _, regionName := range sortedKeys(queryBlock.Data) {
+			if _, ok := block.Data[regionName]; !ok {
+				v.add(memberPath(blockPath+".data", regionName), "is missing, the queryMap has the region")
+			}
+		}
+	}
+	for _, name := range sortedKeys(doc.QueryMap.Blocks) {
+		if _, ok := doc.Results.Blocks[name]; !ok {
+			v.add(memberPath("$.results.blocks", name), "is missing, the queryMap has the block")
+		}
+	}
+}
+
+// checkThresholds checks the threshold settings that the manager computes the significance with
+func (v *validator) checkThresholds(p PlotParams) {
+	if _, err := p.Thresholds(); err != nil {
+		v.add(memberPath("$.plotParams", thresholdMember(err)), "%v", err)
+	}
+}
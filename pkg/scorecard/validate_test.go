@@ -0,0 +1,219 @@
+package scorecard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate_testDocuments(t *testing.T) {
+	paths, err := filepath.Glob(testDocuments)
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no test documents %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if problems := Validate(data); len(problems) != 0 {
+				t.Errorf("Validate() = %v, want no problems", problems)
+			}
+		})
+	}
+}
+
+// surfaceCell is the first cell of the first region of test_Surface.json
+const surfaceCell = `["All HRRR domain"]["Bias (Model - Obs)"]["10m wind"].threshold_NA.level_NA["3"]`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakDoc    func(doc map[string]interface{})
+		wantPath    string
+		wantMessage string
+	}{
+		{
+			name:        "missing plotParams",
+			breakDoc:    func(doc map[string]interface{}) { delete(doc, "plotParams") },
+			wantPath:    "$.plotParams",
+			wantMessage: "is missing",
+		},
+		{
+			name: "curve label",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["curves"].([]interface{})[0].(map[string]interface{})["label"] = 0.0
+			},
+			wantPath:    "$.plotParams.curves[0].label",
+			wantMessage: "is a number, want a string",
+		},
+		{
+			name: "blockTitle",
+			breakDoc: func(doc map[string]interface{}) {
+				delete(object(doc, "results", "blocks", "Block0"), "blockTitle")
+			},
+			wantPath:    "$.results.blocks.Block0.blockTitle",
+			wantMessage: "is missing",
+		},
+		{
+			name: "shallow results region",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "results", "blocks", "Block0", "data", "All HRRR domain", "Bias (Model - Obs)", "10m wind", "threshold_NA")["level_NA"] = -9999.0
+			},
+			wantPath:    `$.results.blocks.Block0.data["All HRRR domain"]["Bias (Model - Obs)"]["10m wind"].threshold_NA.level_NA`,
+			wantMessage: "is a number, want an object",
+		},
+		{
+			name: "query template",
+			breakDoc: func(doc map[string]interface{}) {
+				delete(object(doc, "queryMap", "blocks", "Block0", "data", "All HRRR domain", "Bias (Model - Obs)", "10m wind", "threshold_NA", "level_NA", "3"),
+					"experimentalQueryTemplate")
+			},
+			wantPath:    "$.queryMap.blocks.Block0.data" + surfaceCell + ".experimentalQueryTemplate",
+			wantMessage: "is missing",
+		},
+		{
+			name: "missing query leaf",
+			breakDoc: func(doc map[string]interface{}) {
+				delete(object(doc, "queryMap", "blocks", "Block0", "data", "All HRRR domain", "Bias (Model - Obs)", "10m wind", "threshold_NA", "level_NA"), "3")
+			},
+			wantPath:    "$.queryMap.blocks.Block0.data" + surfaceCell,
+			wantMessage: "is missing, the results have the cell $.results.blocks.Block0.data" + surfaceCell,
+		},
+		{
+			name: "missing query region",
+			breakDoc: func(doc map[string]interface{}) {
+				delete(object(doc, "queryMap", "blocks", "Block0", "data"), "Western HRRR domain")
+			},
+			wantPath:    `$.queryMap.blocks.Block0.data["Western HRRR domain"]`,
+			wantMessage: "is missing, the results have the region",
+		},
+		{
+			name: "missing results block",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "queryMap", "blocks")["Block1"] = object(doc, "queryMap", "blocks", "Block0")
+			},
+			wantPath:    "$.results.blocks.Block1",
+			wantMessage: "is missing, the queryMap has the block",
+		},
+		{
+			name: "no curve for the block",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "results", "blocks", "Block0", "blockTitle")["label"] = "Block9"
+			},
+			wantPath:    "$.results.blocks.Block0.blockTitle.label",
+			wantMessage: `"Block9" is not the label of a plotParams curve`,
+		},
+		{
+			name: "percent threshold",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["minor-threshold-by-percent"] = "ninety five"
+			},
+			wantPath:    `$.plotParams["minor-threshold-by-percent"]`,
+			wantMessage: "error parsing",
+		},
+		{
+			name: "numeric thresholds",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["minor-threshold-by-percent"] = 95.0
+				object(doc, "plotParams")["major-threshold-by-percent"] = true
+			},
+			wantPath:    `$.plotParams["major-threshold-by-percent"]`,
+			wantMessage: "is a boolean, want a string or a number",
+		},
+		{
+			name:        "date range",
+			breakDoc:    func(doc map[string]interface{}) { doc["dateRange"] = "03/24/2023 20:00 - yesterday" },
+			wantPath:    "$.dateRange",
+			wantMessage: `"yesterday" is not a time`,
+		},
+		{
+			name: "exclusion type",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["exclusions"] = []interface{}{"2023-04-01/P1D", 3.0}
+			},
+			wantPath:    "$.plotParams.exclusions[1]",
+			wantMessage: "is a number, want a string",
+		},
+		{
+			name: "exclusion",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["exclusions"] = []interface{}{"2023-04-01/P1D", "2023-04-05"}
+			},
+			wantPath:    "$.plotParams.exclusions[1]",
+			wantMessage: "is not a date range",
+		},
+		{
+			name: "valid hours",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["valid-hours"] = []interface{}{0.0, 12.0, 24.0}
+			},
+			wantPath:    `$.plotParams["valid-hours"][2]`,
+			wantMessage: "is 24, want an hour from 0 to 23",
+		},
+		{
+			name: "init hours",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["init-hours"] = "00Z"
+			},
+			wantPath:    `$.plotParams["init-hours"]`,
+			wantMessage: "is a string, want an array of hours",
+		},
+		{
+			name: "threshold kind",
+			breakDoc: func(doc map[string]interface{}) {
+				object(doc, "plotParams")["scorecard-percent-stdv"] = "Sigma"
+			},
+			wantPath:    `$.plotParams["scorecard-percent-stdv"]`,
+			wantMessage: `is "Sigma"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile("../manager/testdata/test_Surface.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc map[string]interface{}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			tt.breakDoc(doc)
+			data, err = json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := Validate(data)
+			if len(problems) != 1 || problems[0].Path != tt.wantPath || !strings.Contains(problems[0].Message, tt.wantMessage) {
+				t.Errorf("Validate() = %v, want %s: %s", problems, tt.wantPath, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestValidate_notAnObject(t *testing.T) {
+	for _, data := range []string{`[1]`, `{"id": `} {
+		if problems := Validate([]byte(data)); len(problems) != 1 || problems[0].Path != "$" {
+			t.Errorf("Validate(%s) = %v, want a problem at $", data, problems)
+		}
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Problems: []Problem{{"$.a", "x"}, {"$.b", "y"}, {"$.c", "z"}, {"$.d", "w"}, {"$.e", "v"}}}
+	want := "scorecard document has 5 problems: $.a: x; $.b: y; $.c: z; and 2 more"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+// object returns the object at the keys below doc
+func object(doc map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, k := range keys {
+		doc = doc[k].(map[string]interface{})
+	}
+	return doc
+}